@@ -0,0 +1,228 @@
+package gojenkins
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authenticator adds credentials to the headers of every request sent to jenkins.
+type Authenticator interface {
+	Authenticate(ctx context.Context, header http.Header) error
+}
+
+// AuthenticatorFunc is an adapter to allow the use of ordinary functions as an Authenticator.
+type AuthenticatorFunc func(ctx context.Context, header http.Header) error
+
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, header http.Header) error {
+	return f(ctx, header)
+}
+
+// APITokenAuthenticator authenticates using basic auth with a username and its jenkins API token.
+// A password can be used instead of the API token if jenkins allows it.
+func APITokenAuthenticator(username, apiToken string) Authenticator {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + apiToken))
+	return AuthenticatorFunc(func(_ context.Context, header http.Header) error {
+		header.Set("Authorization", "Basic "+credentials)
+		return nil
+	})
+}
+
+// BearerTokenAuthenticator authenticates using a static bearer token,
+// e.g. when jenkins is fronted by an SSO proxy.
+func BearerTokenAuthenticator(token string) Authenticator {
+	return AuthenticatorFunc(func(_ context.Context, header http.Header) error {
+		header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// AnonymousAuthenticator sends requests without any credentials.
+func AnonymousAuthenticator() Authenticator {
+	return AuthenticatorFunc(func(context.Context, http.Header) error {
+		return nil
+	})
+}
+
+// OAuth2Authenticator authenticates using access tokens obtained from src.
+// If jenkins rejects a token cached by ClientCredentialsTokenSource, a new one is obtained for the next request.
+func OAuth2Authenticator(src TokenSource) Authenticator {
+	return oauth2Authenticator{src: src}
+}
+
+type oauth2Authenticator struct {
+	src TokenSource
+}
+
+func (a oauth2Authenticator) Authenticate(ctx context.Context, header http.Header) error {
+	token, err := a.src.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to obtain oauth2 token: %v", err)
+	}
+	tokenType := token.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	header.Set("Authorization", tokenType+" "+token.AccessToken)
+	return nil
+}
+
+func (a oauth2Authenticator) rejected(header http.Header) {
+	if src, ok := a.src.(tokenInvalidator); ok {
+		if _, accessToken, ok := strings.Cut(header.Get("Authorization"), " "); ok {
+			src.invalidate(accessToken)
+		}
+	}
+}
+
+// rejectedAuthenticator is implemented by authenticators which have to know when jenkins rejected
+// the credentials they added to header, e.g. to drop a revoked token.
+type rejectedAuthenticator interface {
+	rejected(header http.Header)
+}
+
+// tokenInvalidator is implemented by token sources which cache tokens.
+type tokenInvalidator interface {
+	invalidate(accessToken string)
+}
+
+// Authenticate returns a Middleware that adds credentials to every request using a.
+func Authenticate(a Authenticator) Middleware {
+	return func(next Requestor) Requestor {
+		return RequestorFunc(func(ctx context.Context, req Request) *Response {
+			req.Header = cloneHeader(req.Header)
+			if err := a.Authenticate(ctx, req.Header); err != nil {
+				return &Response{err: err}
+			}
+			resp := next.Do(ctx, req)
+			if r, ok := a.(rejectedAuthenticator); ok {
+				if httpResp := resp.HTTPResponse(); httpResp != nil && httpResp.StatusCode == http.StatusUnauthorized {
+					r.rejected(req.Header)
+				}
+			}
+			return resp
+		})
+	}
+}
+
+type httpClientKey struct{}
+
+// withHTTPClient returns a copy of ctx carrying the http client of the requestor, so that
+// authenticators can obtain credentials the same way requests are sent to jenkins.
+func withHTTPClient(ctx context.Context, client *http.Client) context.Context {
+	if client == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, httpClientKey{}, client)
+}
+
+// httpClient returns the http client carried by ctx, or nil to use http.DefaultClient.
+func httpClient(ctx context.Context) *http.Client {
+	client, _ := ctx.Value(httpClientKey{}).(*http.Client)
+	return client
+}
+
+// Token is an OAuth2 access token.
+type Token struct {
+	AccessToken string
+	TokenType   string
+	// Expiry is the zero time if the token does not expire.
+	Expiry time.Time
+}
+
+func (t Token) valid() bool {
+	// Refresh a little early so that the token does not expire while a request is in flight.
+	return t.AccessToken != "" && (t.Expiry.IsZero() || time.Now().Add(10*time.Second).Before(t.Expiry))
+}
+
+// TokenSource supplies OAuth2 access tokens.
+// An oauth2.TokenSource from golang.org/x/oauth2 can be adapted using TokenSourceFunc.
+type TokenSource interface {
+	Token(ctx context.Context) (Token, error)
+}
+
+// TokenSourceFunc is an adapter to allow the use of ordinary functions as a TokenSource.
+type TokenSourceFunc func(ctx context.Context) (Token, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (Token, error) {
+	return f(ctx)
+}
+
+// ClientCredentialsTokenSource returns a TokenSource which obtains tokens from tokenURL using the
+// OAuth2 client credentials grant. Tokens are cached until they expire or jenkins rejects them.
+// Used with OAuth2Authenticator, tokens are requested with the http client of the requestor,
+// including its TLS configuration and client certificate.
+func ClientCredentialsTokenSource(tokenURL, clientID, clientSecret string, scopes ...string) TokenSource {
+	return &clientCredentialsTokenSource{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+	}
+}
+
+type clientCredentialsTokenSource struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+
+	mu    sync.Mutex
+	token Token
+}
+
+func (c *clientCredentialsTokenSource) Token(ctx context.Context) (Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token.valid() {
+		return c.token, nil
+	}
+
+	form := url.Values{"grant_type": []string{"client_credentials"}}
+	if len(c.scopes) > 0 {
+		form.Set("scope", strings.Join(c.scopes, " "))
+	}
+
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	err := Chain(sender(httpClient(ctx)), Authenticate(APITokenAuthenticator(url.QueryEscape(c.clientID), url.QueryEscape(c.clientSecret)))).
+		Do(ctx, Request{
+			Method:      http.MethodPost,
+			URL:         c.tokenURL,
+			ContentType: ContentTypeFormURLEncoded,
+			Body:        strings.NewReader(form.Encode()),
+		}).
+		VerifyAndDecode(JsonDecoder(&tokenResponse))
+	if err != nil {
+		return Token{}, err
+	}
+	if tokenResponse.AccessToken == "" {
+		return Token{}, errors.New("token response does not contain an access_token")
+	}
+
+	c.token = Token{AccessToken: tokenResponse.AccessToken, TokenType: tokenResponse.TokenType}
+	if tokenResponse.ExpiresIn > 0 {
+		c.token.Expiry = time.Now().Add(time.Duration(tokenResponse.ExpiresIn) * time.Second)
+	}
+	return c.token, nil
+}
+
+func (c *clientCredentialsTokenSource) invalidate(accessToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A token obtained since the rejected one was sent is kept.
+	if c.token.AccessToken == accessToken {
+		c.token = Token{}
+	}
+}
@@ -0,0 +1,146 @@
+package gojenkins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticators(t *testing.T) {
+	tests := map[string]struct {
+		authenticator         Authenticator
+		expectedAuthorization string
+	}{
+		"api token should use basic auth": {
+			authenticator:         APITokenAuthenticator("user", "token"),
+			expectedAuthorization: "Basic dXNlcjp0b2tlbg==",
+		},
+		"bearer token should use the static token": {
+			authenticator:         BearerTokenAuthenticator("static-token"),
+			expectedAuthorization: "Bearer static-token",
+		},
+		"oauth2 should use the token from the token source": {
+			authenticator: OAuth2Authenticator(TokenSourceFunc(func(context.Context) (Token, error) {
+				return Token{AccessToken: "access-token", TokenType: "bearer"}, nil
+			})),
+			expectedAuthorization: "Bearer access-token",
+		},
+		"anonymous should not send credentials": {
+			authenticator:         AnonymousAuthenticator(),
+			expectedAuthorization: "",
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var authorization string
+			srvr := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				authorization = req.Header.Get("Authorization")
+				fmt.Fprint(resp, listJobsResponse)
+			}))
+			defer srvr.Close()
+
+			api := NewViewAPI(URLBuilder(srvr.URL), NewRequestor(testdata.authenticator))
+			if _, err := api.ListJobNames(context.TODO(), "test-view"); err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+
+			if authorization != testdata.expectedAuthorization {
+				t.Errorf("Expected Authorization %q but got %q", testdata.expectedAuthorization, authorization)
+			}
+		})
+	}
+}
+
+func TestClientCredentialsTokenSource_CachesTokenUntilExpiry(t *testing.T) {
+	var tokenRequests int
+	srvr := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		tokenRequests++
+		if clientID, secret, _ := req.BasicAuth(); clientID != "client" || secret != "secret" {
+			t.Errorf("Expected client credentials client:secret but got %v:%v", clientID, secret)
+		}
+		if err := req.ParseForm(); err != nil || req.PostForm.Get("grant_type") != "client_credentials" || req.PostForm.Get("scope") != "jenkins" {
+			t.Errorf("Unexpected token request form %v", req.PostForm)
+		}
+		fmt.Fprintf(resp, `{"access_token": "token-%v", "token_type": "Bearer", "expires_in": 3600}`, tokenRequests)
+	}))
+	defer srvr.Close()
+
+	src := ClientCredentialsTokenSource(srvr.URL, "client", "secret", "jenkins")
+	for i := 0; i < 2; i++ {
+		token, err := src.Token(context.TODO())
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		if token.AccessToken != "token-1" {
+			t.Errorf("Expected token-1 but got %v", token.AccessToken)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("Expected 1 token request but got %v", tokenRequests)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClientCredentialsTokenSource_UsesTheHTTPClientOfTheRequestor(t *testing.T) {
+	tokenSrvr := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(resp, `{"access_token": "token", "expires_in": 3600}`)
+	}))
+	defer tokenSrvr.Close()
+	srvr := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(resp, listJobsResponse)
+	}))
+	defer srvr.Close()
+
+	var hosts []string
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		hosts = append(hosts, req.URL.Host)
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+	src := ClientCredentialsTokenSource(tokenSrvr.URL, "client", "secret")
+	api := NewViewAPI(URLBuilder(srvr.URL), NewRequestor(OAuth2Authenticator(src)).WithHTTPClient(client))
+	if _, err := api.ListJobNames(context.TODO(), "test-view"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	tokenHost, jenkinsHost := tokenSrvr.Listener.Addr().String(), srvr.Listener.Addr().String()
+	if len(hosts) != 2 || hosts[0] != tokenHost || hosts[1] != jenkinsHost {
+		t.Errorf("Expected the token and jenkins to be requested with the client but got %v", hosts)
+	}
+}
+
+func TestClientCredentialsTokenSource_ObtainsNewTokenWhenRejected(t *testing.T) {
+	var tokenRequests int
+	tokenSrvr := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		tokenRequests++
+		fmt.Fprintf(resp, `{"access_token": "token-%v", "expires_in": 3600}`, tokenRequests)
+	}))
+	defer tokenSrvr.Close()
+	srvr := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") == "Bearer token-1" {
+			resp.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(resp, listJobsResponse)
+	}))
+	defer srvr.Close()
+
+	src := ClientCredentialsTokenSource(tokenSrvr.URL, "client", "secret")
+	api := NewViewAPI(URLBuilder(srvr.URL), NewRequestor(OAuth2Authenticator(src)))
+	if _, err := api.ListJobNames(context.TODO(), "test-view"); !hasStatusCode(err, http.StatusUnauthorized) {
+		t.Fatalf("Expected the revoked token to be rejected but got %v", err)
+	}
+	if _, err := api.ListJobNames(context.TODO(), "test-view"); err != nil {
+		t.Fatalf("Expected a new token to be used but got %v", err)
+	}
+	if tokenRequests != 2 {
+		t.Errorf("Expected 2 token requests but got %v", tokenRequests)
+	}
+}
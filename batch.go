@@ -0,0 +1,201 @@
+package gojenkins
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of builds RunBatch runs at once unless WithConcurrency is given.
+const DefaultBatchConcurrency = 10
+
+// BatchRun is a build run by RunBatch.
+type BatchRun struct {
+	JobName string
+	Params  url.Values
+}
+
+func (r BatchRun) String() string {
+	if len(r.Params) == 0 {
+		return r.JobName
+	}
+	return fmt.Sprintf("%v(%v)", r.JobName, r.Params.Encode())
+}
+
+// Matrix returns a BatchRun of the job for every value of the parameter name, e.g. one per region.
+// Every run also has the params.
+func Matrix(jobName string, params url.Values, name string, values ...string) []BatchRun {
+	runs := make([]BatchRun, 0, len(values))
+	for _, value := range values {
+		runParams := make(url.Values, len(params)+1)
+		for k, v := range params {
+			runParams[k] = append([]string(nil), v...)
+		}
+		runParams.Set(name, value)
+		runs = append(runs, BatchRun{JobName: jobName, Params: runParams})
+	}
+	return runs
+}
+
+// BatchOption configures Client.RunBatch.
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	concurrency int
+	failFast    bool
+	runOpts     []RunOption
+}
+
+// WithConcurrency limits how many builds RunBatch runs at once.
+func WithConcurrency(n int) BatchOption {
+	return func(c *batchConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithFailFast configures RunBatch to stop as soon as a build does not succeed. Builds that are
+// running are cancelled as with WithCancelOnDone and builds that were not scheduled yet are skipped.
+func WithFailFast() BatchOption {
+	return func(c *batchConfig) {
+		c.failFast = true
+	}
+}
+
+// WithRunOptions passes opts to Run for every build of the batch.
+func WithRunOptions(opts ...RunOption) BatchOption {
+	return func(c *batchConfig) {
+		c.runOpts = append(c.runOpts, opts...)
+	}
+}
+
+// BatchRunResult is the outcome of a single build of a batch.
+type BatchRunResult struct {
+	Run    BatchRun
+	Result RunResult
+	Err    error
+	// Skipped reports that the build was never scheduled because the batch failed fast or its context was done.
+	Skipped bool
+}
+
+// Succeeded reports whether the build completed with SUCCESS.
+func (r BatchRunResult) Succeeded() bool {
+	return !r.Skipped && r.Err == nil && r.Result.Build.Result == "SUCCESS"
+}
+
+func (r BatchRunResult) String() string {
+	switch {
+	case r.Skipped:
+		return fmt.Sprintf("%v: skipped", r.Run)
+	case r.Err != nil:
+		return fmt.Sprintf("%v: %v", r.Run, r.Err)
+	}
+	return fmt.Sprintf("%v: #%v %v", r.Run, r.Result.Build.Number, r.Result.Build.Result)
+}
+
+// BatchResult is the outcome of RunBatch.
+type BatchResult struct {
+	// Runs are the results of the builds in the order they were passed to RunBatch.
+	Runs []BatchRunResult
+
+	Succeeded int
+	// Failed counts the builds that failed, completed with another result than SUCCESS or were cancelled.
+	Failed  int
+	Skipped int
+}
+
+// BatchError is returned by RunBatch when not every build succeeded.
+type BatchError struct {
+	Failed  []BatchRunResult
+	Skipped int
+	Total   int
+}
+
+func (e *BatchError) Error() string {
+	failures := make([]string, 0, len(e.Failed))
+	for _, r := range e.Failed {
+		failures = append(failures, r.String())
+	}
+	msg := fmt.Sprintf("%v of %v builds failed: %v", len(e.Failed), e.Total, strings.Join(failures, "; "))
+	if e.Skipped > 0 {
+		msg += fmt.Sprintf(" (%v skipped)", e.Skipped)
+	}
+	return msg
+}
+
+// RunBatch runs the builds with Run, at most DefaultBatchConcurrency at once, and waits for all of them.
+// It returns a *BatchError if not every build succeeded.
+func (c client) RunBatch(ctx context.Context, runs []BatchRun, opts ...BatchOption) (BatchResult, error) {
+	cfg := batchConfig{concurrency: DefaultBatchConcurrency}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	runOpts := cfg.runOpts
+	if cfg.failFast {
+		runOpts = append(runOpts[:len(runOpts):len(runOpts)], WithCancelOnDone())
+	}
+	workers := cfg.concurrency
+	if workers <= 0 || workers > len(runs) {
+		workers = len(runs)
+	}
+
+	ctx, cancelFn := context.WithCancel(ctx)
+	defer cancelFn()
+
+	results := make([]BatchRunResult, len(runs))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					results[i] = BatchRunResult{Run: runs[i], Skipped: true}
+					continue
+				}
+				result, err := c.Run(ctx, runs[i].JobName, runs[i].Params, runOpts...)
+				results[i] = BatchRunResult{Run: runs[i], Result: result, Err: err}
+				if cfg.failFast && !results[i].Succeeded() {
+					cancelFn()
+				}
+			}
+		}()
+	}
+
+	sent := 0
+schedule:
+	for sent < len(runs) {
+		select {
+		case indexes <- sent:
+			sent++
+		case <-ctx.Done():
+			break schedule
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	for i := sent; i < len(runs); i++ {
+		results[i] = BatchRunResult{Run: runs[i], Skipped: true}
+	}
+
+	batch := BatchResult{Runs: results}
+	batchErr := &BatchError{Total: len(runs)}
+	for _, r := range results {
+		switch {
+		case r.Skipped:
+			batch.Skipped++
+		case r.Succeeded():
+			batch.Succeeded++
+		default:
+			batch.Failed++
+			batchErr.Failed = append(batchErr.Failed, r)
+		}
+	}
+	batchErr.Skipped = batch.Skipped
+	if batch.Failed > 0 || batch.Skipped > 0 {
+		return batch, batchErr
+	}
+	return batch, nil
+}
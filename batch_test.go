@@ -0,0 +1,109 @@
+package gojenkins_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venkssa/gojenkins"
+	"github.com/venkssa/gojenkins/gojenkinstest"
+)
+
+func TestClient_RunBatch(t *testing.T) {
+	srv := gojenkinstest.NewServer()
+	defer srv.Close()
+	srv.AddJob(gojenkinstest.Job{
+		Name:       "deploy",
+		Parameters: map[string]string{"region": "", "version": ""},
+		Runs:       []gojenkinstest.Run{{Duration: 40 * time.Millisecond}},
+	})
+	client := gojenkins.NewClient(srv.URL, "", "")
+	runs := gojenkins.Matrix("deploy", url.Values{"version": {"1.2"}}, "region", "us-east-1", "eu-west-1", "ap-south-1", "sa-east-1")
+
+	start := time.Now()
+	result, err := client.RunBatch(context.TODO(), runs, gojenkins.WithConcurrency(2),
+		gojenkins.WithRunOptions(gojenkins.WithPollInterval(time.Millisecond)))
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if result.Succeeded != 4 || result.Failed != 0 || result.Skipped != 0 {
+		t.Errorf("Expected 4 builds to succeed but got %+v", result)
+	}
+	for i, r := range result.Runs {
+		if !reflect.DeepEqual(r.Run, runs[i]) {
+			t.Errorf("Expected results in the order of the runs but got %v at %v", r.Run, i)
+		}
+	}
+	if elapsed < 80*time.Millisecond {
+		t.Errorf("Expected at most 2 builds to run at once but 4 builds of 40ms completed in %v", elapsed)
+	}
+
+	var regions []string
+	for _, b := range srv.Builds("deploy") {
+		if b.Parameters["version"] != "1.2" {
+			t.Errorf("Expected version 1.2 but got %v", b.Parameters)
+		}
+		regions = append(regions, b.Parameters["region"])
+	}
+	if len(regions) != 4 {
+		t.Errorf("Expected a build for every region but got %v", regions)
+	}
+}
+
+func TestClient_RunBatch_FailsFast(t *testing.T) {
+	srv := gojenkinstest.NewServer()
+	defer srv.Close()
+	srv.AddJob(gojenkinstest.Job{Name: "fails", Runs: []gojenkinstest.Run{{Duration: 10 * time.Millisecond, Result: "FAILURE"}}})
+	for _, name := range []string{"slow-1", "slow-2", "slow-3"} {
+		srv.AddJob(gojenkinstest.Job{Name: name, Runs: []gojenkinstest.Run{{Duration: time.Hour}}})
+	}
+	client := gojenkins.NewClient(srv.URL, "", "")
+	runs := []gojenkins.BatchRun{{JobName: "fails"}, {JobName: "slow-1"}, {JobName: "slow-2"}, {JobName: "slow-3"}}
+
+	result, err := client.RunBatch(context.TODO(), runs, gojenkins.WithConcurrency(2), gojenkins.WithFailFast(),
+		gojenkins.WithRunOptions(gojenkins.WithPollInterval(time.Millisecond)))
+
+	var batchErr *gojenkins.BatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Failed) != 2 || batchErr.Skipped != 2 {
+		t.Fatalf("Expected a batch error with 2 failed and 2 skipped builds but got %v", err)
+	}
+	if !strings.Contains(err.Error(), "fails: #1 FAILURE") {
+		t.Errorf("Expected the error to describe the failed build but got %v", err)
+	}
+	if result.Failed != 2 || result.Skipped != 2 || !result.Runs[2].Skipped || !result.Runs[3].Skipped {
+		t.Errorf("Expected the remaining builds to be skipped but got %+v", result)
+	}
+	if !result.Runs[1].Result.Cancelled {
+		t.Errorf("Expected the running build to be aborted but got %+v", result.Runs[1])
+	}
+	if builds, _ := client.GetBuilds(context.TODO(), "slow-1", 0, 1); len(builds) != 1 || builds[0].Result != "ABORTED" {
+		t.Errorf("Expected slow-1 to be ABORTED but got %v", builds)
+	}
+	if builds := srv.Builds("slow-2"); len(builds) != 0 {
+		t.Errorf("Expected slow-2 not to be scheduled but got %v", builds)
+	}
+}
+
+func TestClient_RunBatch_ReportsFailuresWithoutFailingFast(t *testing.T) {
+	srv := gojenkinstest.NewServer()
+	defer srv.Close()
+	srv.AddJob(gojenkinstest.Job{Name: "unstable", Runs: []gojenkinstest.Run{{Result: "UNSTABLE"}}})
+	srv.AddJob(gojenkinstest.Job{Name: "stable"})
+	client := gojenkins.NewClient(srv.URL, "", "")
+	runs := []gojenkins.BatchRun{{JobName: "unstable"}, {JobName: "stable"}, {JobName: "missing"}}
+
+	result, err := client.RunBatch(context.TODO(), runs, gojenkins.WithRunOptions(gojenkins.WithPollInterval(time.Millisecond)))
+
+	if err == nil || !strings.Contains(err.Error(), "2 of 3 builds failed") {
+		t.Fatalf("Expected 2 of 3 builds to fail but got %v", err)
+	}
+	if result.Succeeded != 1 || result.Failed != 2 || result.Skipped != 0 {
+		t.Errorf("Expected 1 succeeded and 2 failed builds but got %+v", result)
+	}
+}
@@ -0,0 +1,228 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// ErrUnsupported is returned, wrapped with the missing feature, when jenkins lacks the plugin
+// required by an operation. It is errors.ErrUnsupported.
+var ErrUnsupported = errors.ErrUnsupported
+
+// Plugins which optional features of the client depend on.
+const (
+	pluginPipelineStageView = "pipeline-stage-view"
+	pluginSSEGateway        = "sse-gateway"
+	pluginFolders           = "cloudbees-folder"
+	pluginBranchAPI         = "branch-api"
+	pluginCredentials       = "credentials"
+)
+
+// Capabilities are the optional features of a jenkins controller.
+type Capabilities struct {
+	Version string
+
+	// CrumbIssuer reports whether CSRF protection is enabled.
+	CrumbIssuer bool
+
+	// PluginsKnown reports whether the installed plugins could be listed, which requires the
+	// Overall/SystemRead or Administer permission. The plugin capabilities below are reported
+	// as present if they are not known.
+	PluginsKnown bool
+	// PipelineStageView reports whether the pipeline stage view api (wfapi) is available.
+	PipelineStageView bool
+	// SSEGateway reports whether build events can be subscribed to, see EventSubscriber.
+	SSEGateway bool
+	// Folders reports whether jobs can be organized in folders.
+	Folders bool
+	// Multibranch reports whether multibranch projects and organization folders are available, see MultibranchAPI.
+	Multibranch bool
+	// Credentials reports whether credentials can be managed, see CredentialsAPI.
+	Credentials bool
+}
+
+// CapabilitiesAPI is the interface to detect the version and optional features of Jenkins.
+type CapabilitiesAPI interface {
+	// Version returns the version jenkins reports in the X-Jenkins header. It is read from the
+	// first response received by the client, jenkins is only asked for it if there was none.
+	Version(ctx context.Context) (string, error)
+
+	// Capabilities probes jenkins for optional features. The result is cached for the lifetime of the client.
+	Capabilities(ctx context.Context) (Capabilities, error)
+}
+
+// NewCapabilitiesAPI returns a CapabilitiesAPI. Its RecordVersion middleware has to be added to r
+// for Version to be read from responses of other requests.
+func NewCapabilitiesAPI(u URLBuilder, r Requestor) *capabilitiesAPI {
+	return &capabilitiesAPI{URLBuilder: u, requestor: r, plugins: NewPluginAPI(u, r)}
+}
+
+type capabilitiesAPI struct {
+	URLBuilder
+	requestor Requestor
+	plugins   pluginAPI
+
+	versionMu sync.Mutex
+	version   string
+
+	mu           sync.Mutex
+	capabilities *Capabilities
+}
+
+// RecordVersion is a Middleware which records the version of jenkins from the first response with an X-Jenkins header.
+func (c *capabilitiesAPI) RecordVersion(next Requestor) Requestor {
+	return RequestorFunc(func(ctx context.Context, req Request) *Response {
+		resp := next.Do(ctx, req)
+		if httpResp := resp.HTTPResponse(); httpResp != nil {
+			c.recordVersion(httpResp.Header.Get("X-Jenkins"))
+		}
+		return resp
+	})
+}
+
+func (c *capabilitiesAPI) recordVersion(version string) {
+	if version == "" {
+		return
+	}
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+	if c.version == "" {
+		c.version = version
+	}
+}
+
+func (c *capabilitiesAPI) recordedVersion() string {
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+	return c.version
+}
+
+func (c *capabilitiesAPI) Version(ctx context.Context) (string, error) {
+	if version := c.recordedVersion(); version != "" {
+		return version, nil
+	}
+
+	var version string
+	recordVersion := func(resp *http.Response) error {
+		version = resp.Header.Get("X-Jenkins")
+		return nil
+	}
+	err := c.requestor.
+		Do(ctx, Request{
+			Method: http.MethodGet,
+			URL:    c.URLBuilder.JSONEndpoint(),
+			Route:  "api/json",
+			Query:  url.Values{"tree": []string{"mode"}},
+		}).
+		VerifyAndDecode(NoOpDecoder, StatusOKVerifier, recordVersion)
+	if err != nil {
+		return "", err
+	}
+	if version == "" {
+		return "", errors.New("jenkins did not report its version in the X-Jenkins header")
+	}
+	c.recordVersion(version)
+	return version, nil
+}
+
+func (c *capabilitiesAPI) Capabilities(ctx context.Context) (Capabilities, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capabilities != nil {
+		return *c.capabilities, nil
+	}
+
+	var capabilities Capabilities
+	var err error
+	if capabilities.Version, err = c.Version(ctx); err != nil {
+		return Capabilities{}, err
+	}
+
+	err = c.requestor.
+		Do(ctx, Request{Method: http.MethodGet, URL: c.URLBuilder.JSONEndpoint("crumbIssuer"), Route: "crumbIssuer/api/json"}).
+		VerifyAndDecode(NoOpDecoder)
+	switch {
+	case err == nil:
+		capabilities.CrumbIssuer = true
+	case !hasStatusCode(err, http.StatusNotFound):
+		return Capabilities{}, err
+	}
+
+	plugins, err := c.plugins.ListPlugins(ctx)
+	switch {
+	case err == nil:
+		capabilities.PluginsKnown = true
+		active := make(map[string]bool, len(plugins))
+		for _, plugin := range plugins {
+			active[plugin.ShortName] = plugin.Active
+		}
+		capabilities.PipelineStageView = active[pluginPipelineStageView]
+		capabilities.SSEGateway = active[pluginSSEGateway]
+		capabilities.Folders = active[pluginFolders]
+		capabilities.Multibranch = active[pluginBranchAPI]
+		capabilities.Credentials = active[pluginCredentials]
+	case hasStatusCode(err, http.StatusUnauthorized, http.StatusForbidden):
+		capabilities.PipelineStageView = true
+		capabilities.SSEGateway = true
+		capabilities.Folders = true
+		capabilities.Multibranch = true
+		capabilities.Credentials = true
+	default:
+		return Capabilities{}, err
+	}
+
+	c.capabilities = &capabilities
+	return capabilities, nil
+}
+
+// lacks reports whether jenkins is known to lack a capability. It is false if c is nil or the
+// capabilities cannot be detected, requests are then made and fail on their own.
+func (c *capabilitiesAPI) lacks(ctx context.Context, has func(Capabilities) bool) bool {
+	if c == nil {
+		return false
+	}
+	capabilities, err := c.Capabilities(ctx)
+	return err == nil && !has(capabilities)
+}
+
+// missingAsUnsupported wraps a 404 with ErrUnsupported if jenkins lacks the plugin of the feature.
+// Other 404s, e.g. for a missing job, are returned as they are.
+func (c *capabilitiesAPI) missingAsUnsupported(ctx context.Context, err error, feature string, has func(Capabilities) bool) error {
+	if hasStatusCode(err, http.StatusNotFound) && c.lacks(ctx, has) {
+		return fmt.Errorf("%v: %w", feature, ErrUnsupported)
+	}
+	return err
+}
+
+func hasFolders(c Capabilities) bool           { return c.Folders }
+func hasSSEGateway(c Capabilities) bool        { return c.SSEGateway }
+func hasPipelineStageView(c Capabilities) bool { return c.PipelineStageView }
+func hasMultibranch(c Capabilities) bool       { return c.Multibranch }
+func hasCredentials(c Capabilities) bool       { return c.Credentials }
+
+// hasStatusCode reports whether err is a StatusCodeError with one of the status codes.
+func hasStatusCode(err error, statusCodes ...int) bool {
+	var statusErr StatusCodeError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	for _, statusCode := range statusCodes {
+		if statusErr.StatusCode == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// notFoundAsUnsupported wraps a 404 from an endpoint of a plugin with ErrUnsupported as the plugin is missing.
+func notFoundAsUnsupported(err error, feature string) error {
+	if hasStatusCode(err, http.StatusNotFound) {
+		return fmt.Errorf("%v: %w", feature, ErrUnsupported)
+	}
+	return err
+}
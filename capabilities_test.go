@@ -0,0 +1,293 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCapabilitiesAPI_VersionIsRecordedFromResponses(t *testing.T) {
+	var requests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(resp http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		resp.Header().Set("X-Jenkins", "2.426.3")
+		fmt.Fprint(resp, queueAPIResponse)
+	})
+	srvr := httptest.NewServer(mux)
+	defer srvr.Close()
+	client := NewClient(srvr.URL, "", "")
+
+	if _, err := client.QueueStats(context.TODO()); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	version, err := client.Version(context.TODO())
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if version != "2.426.3" {
+		t.Errorf("Expected version 2.426.3 but got %v", version)
+	}
+	if requests != 1 {
+		t.Errorf("Expected the version to be read from the previous response but jenkins was asked %v times", requests)
+	}
+}
+
+func TestCapabilitiesAPI_VersionIsRequestedIfNotRecorded(t *testing.T) {
+	api, cleanupFn := capabilitiesAPITestClient(map[string]http.HandlerFunc{
+		"/api/json": func(resp http.ResponseWriter, req *http.Request) {
+			resp.Header().Set("X-Jenkins", "2.426.3")
+			fmt.Fprint(resp, `{"mode":"NORMAL"}`)
+		},
+	})
+	defer cleanupFn()
+
+	version, err := api.Version(context.TODO())
+	if err != nil || version != "2.426.3" {
+		t.Errorf("Expected version 2.426.3 but got %v, %v", version, err)
+	}
+}
+
+func TestCapabilitiesAPI_Capabilities(t *testing.T) {
+	jenkinsVersion := func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("X-Jenkins", "2.426.3")
+		fmt.Fprint(resp, `{}`)
+	}
+	tests := map[string]struct {
+		handlers             map[string]http.HandlerFunc
+		expectedCapabilities Capabilities
+	}{
+		"should detect the crumb issuer and plugins": {
+			handlers: map[string]http.HandlerFunc{
+				"/api/json":             jenkinsVersion,
+				"/crumbIssuer/api/json": stringResponseHandleFunc(`{"crumb":"abc","crumbRequestField":"Jenkins-Crumb"}`),
+				"/pluginManager/api/json": stringResponseHandleFunc(`{"plugins":[
+					{"shortName":"cloudbees-folder","active":true},
+					{"shortName":"pipeline-stage-view","active":false},
+					{"shortName":"git","active":true}]}`),
+			},
+			expectedCapabilities: Capabilities{Version: "2.426.3", CrumbIssuer: true, PluginsKnown: true, Folders: true},
+		},
+		"should detect the multibranch and credentials plugins": {
+			handlers: map[string]http.HandlerFunc{
+				"/api/json": jenkinsVersion,
+				"/pluginManager/api/json": stringResponseHandleFunc(`{"plugins":[
+					{"shortName":"branch-api","active":true},
+					{"shortName":"credentials","active":true}]}`),
+			},
+			expectedCapabilities: Capabilities{Version: "2.426.3", PluginsKnown: true, Multibranch: true, Credentials: true},
+		},
+		"should assume plugins are present if they cannot be listed": {
+			handlers: map[string]http.HandlerFunc{
+				"/api/json": jenkinsVersion,
+				"/pluginManager/api/json": func(resp http.ResponseWriter, req *http.Request) {
+					resp.WriteHeader(http.StatusForbidden)
+				},
+			},
+			expectedCapabilities: Capabilities{
+				Version: "2.426.3", PipelineStageView: true, SSEGateway: true, Folders: true, Multibranch: true, Credentials: true,
+			},
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			api, cleanupFn := capabilitiesAPITestClient(testdata.handlers)
+			defer cleanupFn()
+
+			capabilities, err := api.Capabilities(context.TODO())
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if capabilities != testdata.expectedCapabilities {
+				t.Errorf("Expected %+v but got %+v", testdata.expectedCapabilities, capabilities)
+			}
+		})
+	}
+}
+
+func TestCapabilitiesAPI_CapabilitiesAreCached(t *testing.T) {
+	var requests int32
+	counting := func(fn http.HandlerFunc) http.HandlerFunc {
+		return func(resp http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			fn(resp, req)
+		}
+	}
+	api, cleanupFn := capabilitiesAPITestClient(map[string]http.HandlerFunc{
+		"/api/json": counting(func(resp http.ResponseWriter, req *http.Request) {
+			resp.Header().Set("X-Jenkins", "2.426.3")
+		}),
+		"/crumbIssuer/api/json":   counting(stringResponseHandleFunc(`{}`)),
+		"/pluginManager/api/json": counting(stringResponseHandleFunc(`{"plugins":[]}`)),
+	})
+	defer cleanupFn()
+
+	for i := 0; i < 2; i++ {
+		if _, err := api.Capabilities(context.TODO()); err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+	}
+	if requests != 3 {
+		t.Errorf("Expected jenkins to be probed once with 3 requests but got %v", requests)
+	}
+}
+
+func TestJobApi_RunningStageSkipsMissingStageView(t *testing.T) {
+	var describeRequests int32
+	api, cleanupFn := capabilitiesAPITestClient(map[string]http.HandlerFunc{
+		"/api/json": func(resp http.ResponseWriter, req *http.Request) {
+			resp.Header().Set("X-Jenkins", "2.426.3")
+		},
+		"/pluginManager/api/json": stringResponseHandleFunc(`{"plugins":[]}`),
+		"/job/Test/2/wfapi/describe": func(resp http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&describeRequests, 1)
+		},
+	})
+	defer cleanupFn()
+	jobs := NewJobAPI(api.URLBuilder, api.requestor)
+	jobs.capabilities = api
+
+	if stage := jobs.runningStage(context.TODO(), QueueItem{URL: string(api.URLBuilder) + "/job/Test/2/"}); stage != "" {
+		t.Errorf("Expected no stage but got %v", stage)
+	}
+	if describeRequests != 0 {
+		t.Errorf("Expected wfapi not to be requested without the pipeline stage view plugin")
+	}
+}
+
+func TestCapabilitiesAPI_NotFoundIsUnsupportedWithoutThePlugin(t *testing.T) {
+	tests := map[string]struct {
+		plugins             string
+		expectedUnsupported bool
+	}{
+		"should be unsupported without the plugins": {plugins: `{"plugins":[]}`, expectedUnsupported: true},
+		"should be not found with the plugins": {
+			plugins:             `{"plugins":[{"shortName":"branch-api","active":true},{"shortName":"credentials","active":true}]}`,
+			expectedUnsupported: false,
+		},
+	}
+	calls := map[string]func(*capabilitiesAPI) error{
+		"ListBranches": func(api *capabilitiesAPI) error {
+			multibranch := NewMultibranchAPI(api.URLBuilder, api.requestor)
+			multibranch.capabilities = api
+			_, err := multibranch.ListBranches(context.TODO(), "project")
+			return err
+		},
+		"MultibranchScanLog": func(api *capabilitiesAPI) error {
+			multibranch := NewMultibranchAPI(api.URLBuilder, api.requestor)
+			multibranch.capabilities = api
+			_, err := multibranch.MultibranchScanLog(context.TODO(), "project")
+			return err
+		},
+		"ListCredentials": func(api *capabilitiesAPI) error {
+			credentials := NewCredentialsAPI(api.URLBuilder, api.requestor)
+			credentials.capabilities = api
+			_, err := credentials.ListCredentials(context.TODO(), SystemCredentialStore, GlobalCredentialDomain)
+			return err
+		},
+		"DeleteCredential": func(api *capabilitiesAPI) error {
+			credentials := NewCredentialsAPI(api.URLBuilder, api.requestor)
+			credentials.capabilities = api
+			return credentials.DeleteCredential(context.TODO(), SystemCredentialStore, GlobalCredentialDomain, "id")
+		},
+	}
+
+	for testName, testdata := range tests {
+		for callName, call := range calls {
+			t.Run(testName+"/"+callName, func(t *testing.T) {
+				api, cleanupFn := capabilitiesAPITestClient(map[string]http.HandlerFunc{
+					"/api/json": func(resp http.ResponseWriter, req *http.Request) {
+						resp.Header().Set("X-Jenkins", "2.426.3")
+					},
+					"/pluginManager/api/json": stringResponseHandleFunc(testdata.plugins),
+				})
+				defer cleanupFn()
+
+				err := call(api)
+				if errors.Is(err, ErrUnsupported) != testdata.expectedUnsupported {
+					t.Errorf("Expected unsupported %v but got %v", testdata.expectedUnsupported, err)
+				}
+				if !testdata.expectedUnsupported && !hasStatusCode(err, http.StatusNotFound) {
+					t.Errorf("Expected a 404 but got %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestJobApi_ListJobsDoesNotWalkWithoutFolders(t *testing.T) {
+	var folderRequests int32
+	api, cleanupFn := capabilitiesAPITestClient(map[string]http.HandlerFunc{
+		"/api/json": func(resp http.ResponseWriter, req *http.Request) {
+			resp.Header().Set("X-Jenkins", "2.426.3")
+			fmt.Fprint(resp, `{"jobs":[{"name":"project","fullName":"project","jobs":[]}]}`)
+		},
+		"/pluginManager/api/json": stringResponseHandleFunc(`{"plugins":[]}`),
+		"/job/project/api/json": func(resp http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&folderRequests, 1)
+			fmt.Fprint(resp, `{"jobs":[]}`)
+		},
+	})
+	defer cleanupFn()
+	jobs := NewJobAPI(api.URLBuilder, api.requestor)
+	jobs.capabilities = api
+
+	list, err := jobs.ListJobs(context.TODO())
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(list) != 1 {
+		t.Errorf("Expected the root job but got %+v", list)
+	}
+	if folderRequests != 0 {
+		t.Errorf("Expected no folder to be walked without the folders plugin")
+	}
+}
+
+func TestEventSubscriber_DoesNotConnectWithoutTheSSEGateway(t *testing.T) {
+	var connectRequests int32
+	api, cleanupFn := capabilitiesAPITestClient(map[string]http.HandlerFunc{
+		"/api/json": func(resp http.ResponseWriter, req *http.Request) {
+			resp.Header().Set("X-Jenkins", "2.426.3")
+		},
+		"/pluginManager/api/json": stringResponseHandleFunc(`{"plugins":[]}`),
+		"/sse-gateway/": func(resp http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&connectRequests, 1)
+			resp.WriteHeader(http.StatusInternalServerError)
+		},
+	})
+	defer cleanupFn()
+	subscriber := NewEventSubscriber(api.URLBuilder, api.requestor)
+	subscriber.capabilities = api
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := subscriber.subscribe(context.TODO(), func(JobEvent) bool { return true }); err != errEventsUnavailable {
+			t.Errorf("Expected %v but got %v", errEventsUnavailable, err)
+		}
+	}
+	if connectRequests != 0 {
+		t.Errorf("Expected the sse gateway not to be requested without the plugin")
+	}
+}
+
+func TestErrUnsupported(t *testing.T) {
+	if !errors.Is(fmt.Errorf("folders: %w", ErrUnsupported), errors.ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported to match errors.ErrUnsupported")
+	}
+}
+
+func capabilitiesAPITestClient(handlers map[string]http.HandlerFunc) (*capabilitiesAPI, func()) {
+	mux := http.NewServeMux()
+	for path, fn := range handlers {
+		mux.HandleFunc(path, fn)
+	}
+
+	srvr := httptest.NewServer(mux)
+	api := NewCapabilitiesAPI(URLBuilder(srvr.URL), BasicAuthRequestor("", ""))
+	return api, srvr.Close
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/venkssa/gojenkins"
+)
+
+// environment holds what every command needs to talk to jenkins and print its output.
+type environment struct {
+	stdout  io.Writer
+	stderr  io.Writer
+	profile string
+	json    bool
+}
+
+func (env *environment) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.BoolVar(&env.json, "json", false, "print json instead of a table")
+	return fs
+}
+
+func (env *environment) client() (gojenkins.Client, gojenkins.URLBuilder, error) {
+	cfg, err := gojenkins.LoadConfig(env.profile)
+	if err != nil {
+		return nil, "", err
+	}
+	return gojenkins.NewClient(cfg.URL, cfg.Username, cfg.APIToken), gojenkins.URLBuilder(cfg.URL), nil
+}
+
+// print writes v as json or calls table to write a table depending on the -json flag.
+func (env *environment) print(v interface{}, table func(w io.Writer)) error {
+	if env.json {
+		enc := json.NewEncoder(env.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+// params collects repeated -p K=V flags.
+type params url.Values
+
+func (p params) String() string {
+	return url.Values(p).Encode()
+}
+
+func (p params) Set(value string) error {
+	kv := strings.SplitN(value, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return fmt.Errorf("parameter %q is not in the form K=V", value)
+	}
+	url.Values(p).Add(kv[0], kv[1])
+	return nil
+}
+
+var resultExitCodes = map[string]int{
+	"SUCCESS":   exitOK,
+	"FAILURE":   10,
+	"UNSTABLE":  11,
+	"ABORTED":   12,
+	"NOT_BUILT": 13,
+}
+
+type buildOutput struct {
+	QueueID gojenkins.QueueID     `json:"queueId"`
+	Number  gojenkins.BuildNumber `json:"number,omitempty"`
+	URL     string                `json:"url,omitempty"`
+	Result  string                `json:"result,omitempty"`
+}
+
+func runBuild(ctx context.Context, env *environment, args []string) (int, error) {
+	fs := env.flagSet("build")
+	buildParams := make(params)
+	fs.Var(buildParams, "p", "build parameter in the form K=V, can be repeated")
+	wait := fs.Bool("wait", false, "wait for the build to complete and exit with a code mapped from its result")
+	follow := fs.Bool("follow", false, "stream the console output of the build, implies -wait")
+	timeout := fs.Duration("timeout", gojenkins.DefaultWaitForBuildToBeCompletedTimeout, "how long to wait for the build")
+	poll := fs.Duration("poll", 5*time.Second, "how often to poll jenkins while waiting")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil || len(positional) != 1 {
+		return exitUsage, errUsage
+	}
+	jobName := positional[0]
+
+	client, _, err := env.client()
+	if err != nil {
+		return exitError, err
+	}
+
+	queueID, err := client.ScheduleBuild(ctx, jobName, url.Values(buildParams))
+	if err != nil {
+		return exitError, err
+	}
+	output := buildOutput{QueueID: queueID}
+	if !*wait && !*follow {
+		return exitOK, env.print(output, func(w io.Writer) {
+			fmt.Fprintf(w, "Scheduled %v, queue item %v\n", jobName, queueID)
+		})
+	}
+
+	ctx, cancelFn := context.WithTimeout(ctx, *timeout)
+	defer cancelFn()
+
+	// Report progress on stderr unless the console output already shows it.
+	var waitOpts []gojenkins.WaitOption
+	if !*follow {
+		progress := &progressPrinter{w: env.stderr}
+		waitOpts = append(waitOpts, gojenkins.WithQueueProgress(progress.queue), gojenkins.WithBuildProgress(progress.build))
+	}
+
+	item, err := client.WaitUntilBuildIsQueued(ctx, queueID, *poll, waitOpts...)
+	if err != nil {
+		return exitError, err
+	}
+	output.Number, output.URL = item.Number, item.URL
+	if !env.json {
+		fmt.Fprintf(env.stdout, "Started %v #%v %v\n", jobName, item.Number, item.URL)
+	}
+
+	if *follow {
+		// Keep stdout parseable when printing json.
+		console := env.stdout
+		if env.json {
+			console = env.stderr
+		}
+		if err := client.StreamConsoleOutput(ctx, item, console, *poll); err != nil {
+			return exitError, err
+		}
+	}
+
+	info, err := client.WaitUntilBuildIsComplete(ctx, item, *poll, waitOpts...)
+	if err != nil {
+		return exitError, err
+	}
+	output.Result = info.Result
+
+	code, ok := resultExitCodes[info.Result]
+	if !ok {
+		code = exitError
+	}
+	return code, env.print(output, func(w io.Writer) {
+		fmt.Fprintf(w, "Finished %v #%v: %v\n", jobName, info.Number, info.Result)
+	})
+}
+
+// progressPrinter prints a line every time the state of a waited for build changes.
+type progressPrinter struct {
+	w    io.Writer
+	last string
+}
+
+func (p *progressPrinter) queue(progress gojenkins.QueueProgress) {
+	if progress.Why != "" {
+		p.print(fmt.Sprintf("Waiting in queue: %v", progress.Why))
+	}
+}
+
+func (p *progressPrinter) build(progress gojenkins.BuildProgress) {
+	if !progress.Info.Building {
+		return
+	}
+	line := fmt.Sprintf("Building #%v", progress.Info.Number)
+	if progress.Percent >= 0 {
+		line += fmt.Sprintf(": %v%%", progress.Percent)
+		if remaining := progress.Remaining(); remaining > 0 {
+			line += fmt.Sprintf(", about %v left", remaining.Round(time.Second))
+		}
+	}
+	if progress.Stage != "" {
+		line += fmt.Sprintf(" [%v]", progress.Stage)
+	}
+	p.print(line)
+}
+
+func (p *progressPrinter) print(line string) {
+	if line == p.last {
+		return
+	}
+	p.last = line
+	fmt.Fprintln(p.w, line)
+}
+
+func runBuilds(ctx context.Context, env *environment, args []string) (int, error) {
+	fs := env.flagSet("builds")
+	count := fs.Uint("n", 10, "number of builds to list")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil || len(positional) != 1 {
+		return exitUsage, errUsage
+	}
+
+	client, _, err := env.client()
+	if err != nil {
+		return exitError, err
+	}
+	builds, err := client.GetBuilds(ctx, positional[0], 0, uint32(*count))
+	if err != nil {
+		return exitError, err
+	}
+
+	return exitOK, env.print(builds, func(w io.Writer) {
+		fmt.Fprintln(w, "NUMBER\tRESULT\tQUEUE ID\tURL")
+		for _, b := range builds {
+			result := b.Result
+			if result == "" {
+				result = "BUILDING"
+			}
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", b.Number, result, b.QueueID, b.URL)
+		}
+	})
+}
+
+func runQueue(ctx context.Context, env *environment, args []string) (int, error) {
+	fs := env.flagSet("queue")
+	if positional, err := parseInterleaved(fs, args); err != nil || len(positional) != 0 {
+		return exitUsage, errUsage
+	}
+
+	client, _, err := env.client()
+	if err != nil {
+		return exitError, err
+	}
+	items, err := client.ListQueue(ctx)
+	if err != nil {
+		return exitError, err
+	}
+
+	return exitOK, env.print(items, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tJOB\tWAITING\tWHY")
+		for _, item := range items {
+			waiting := time.Since(item.InQueueSince).Round(time.Second)
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", item.ID, item.TaskName, waiting, item.Why)
+		}
+	})
+}
+
+func runViews(ctx context.Context, env *environment, args []string) (int, error) {
+	fs := env.flagSet("views")
+	if positional, err := parseInterleaved(fs, args); err != nil || len(positional) != 0 {
+		return exitUsage, errUsage
+	}
+
+	client, _, err := env.client()
+	if err != nil {
+		return exitError, err
+	}
+	names, err := client.ListViewNames(ctx)
+	if err != nil {
+		return exitError, err
+	}
+	return exitOK, env.print(names, printNames(names))
+}
+
+func runJobs(ctx context.Context, env *environment, args []string) (int, error) {
+	fs := env.flagSet("jobs")
+	re := regexp.MustCompile("")
+	fs.Func("match", "only list jobs whose full name matches the regular expression", func(value string) (err error) {
+		re, err = regexp.Compile(value)
+		return err
+	})
+	positional, err := parseInterleaved(fs, args)
+	if err != nil || len(positional) > 1 {
+		return exitUsage, errUsage
+	}
+
+	client, _, err := env.client()
+	if err != nil {
+		return exitError, err
+	}
+	if len(positional) == 1 {
+		names, err := client.ListJobNames(ctx, positional[0])
+		if err != nil {
+			return exitError, err
+		}
+		matching := []string{}
+		for _, name := range names {
+			if re.MatchString(name) {
+				matching = append(matching, name)
+			}
+		}
+		return exitOK, env.print(matching, printNames(matching))
+	}
+
+	jobs, err := client.ListJobs(ctx, gojenkins.WithJobNameMatching(re))
+	if err != nil {
+		return exitError, err
+	}
+	return exitOK, env.print(jobs, func(w io.Writer) {
+		for _, job := range jobs {
+			fmt.Fprintln(w, job.FullName)
+		}
+	})
+}
+
+func printNames(names []string) func(w io.Writer) {
+	return func(w io.Writer) {
+		for _, name := range names {
+			fmt.Fprintln(w, name)
+		}
+	}
+}
+
+func runCancel(ctx context.Context, env *environment, args []string) (int, error) {
+	fs := env.flagSet("cancel")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil || len(positional) < 1 || len(positional) > 2 {
+		return exitUsage, errUsage
+	}
+	number, err := strconv.ParseUint(positional[len(positional)-1], 10, 32)
+	if err != nil {
+		return exitUsage, errUsage
+	}
+
+	client, urlBuilder, err := env.client()
+	if err != nil {
+		return exitError, err
+	}
+
+	if len(positional) == 1 {
+		if err := client.CancelQueueItem(ctx, gojenkins.QueueID(number)); err != nil {
+			return exitError, err
+		}
+		return exitOK, env.print(map[string]interface{}{"queueId": number, "cancelled": true}, func(w io.Writer) {
+			fmt.Fprintf(w, "Cancelled queue item %v\n", number)
+		})
+	}
+
+	jobName := positional[0]
+	item := gojenkins.QueueItem{
+		Number: gojenkins.BuildNumber(number),
+		URL:    urlBuilder.BuildURL(jobName, gojenkins.BuildNumber(number)),
+	}
+	if err := client.StopBuild(ctx, item); err != nil {
+		return exitError, err
+	}
+	return exitOK, env.print(map[string]interface{}{"job": jobName, "number": number, "aborted": true}, func(w io.Writer) {
+		fmt.Fprintf(w, "Aborted %v #%v\n", jobName, number)
+	})
+}
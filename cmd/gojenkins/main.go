@@ -0,0 +1,119 @@
+// Command gojenkins is a command line client for Jenkins built on the gojenkins package.
+//
+// The jenkins url and credentials are resolved by gojenkins.LoadConfig from the JENKINS_URL,
+// JENKINS_USER and JENKINS_API_TOKEN environment variables, ~/.netrc and the profiles file.
+//
+// Usage:
+//
+//	gojenkins [-profile name] <command> [flags] [args]
+//
+//	build <job> [-p K=V]... [-wait] [-follow]   schedule a build, optionally wait for it and stream its console
+//	builds <job> [-n count]                     list the most recent builds of a job
+//	queue                                       list the items waiting in the queue
+//	views                                       list the views
+//	jobs [<view>] [-match regex]                list the jobs of a view or of the whole instance
+//	cancel <queue-id> | cancel <job> <number>   cancel a queued item or abort a running build
+//
+// Every command accepts -json to print machine readable output instead of a table.
+//
+// When build waits for the build to complete, the exit code reflects its result:
+// 0 for SUCCESS, 10 for FAILURE, 11 for UNSTABLE, 12 for ABORTED and 13 for NOT_BUILT.
+// Other errors exit with 1 and usage errors with 2. Unless -follow is given, build reports why it is
+// waiting in the queue and the progress of the build on stderr while it waits.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, env *environment, args []string) (int, error)
+}
+
+var commands = map[string]command{
+	"build":  {"build <job> [-p K=V]... [-wait] [-follow] [-timeout d] [-poll d] [-json]", runBuild},
+	"builds": {"builds <job> [-n count] [-json]", runBuilds},
+	"queue":  {"queue [-json]", runQueue},
+	"views":  {"views [-json]", runViews},
+	"jobs":   {"jobs [<view>] [-match regex] [-json]", runJobs},
+	"cancel": {"cancel <queue-id> | cancel <job> <number> [-json]", runCancel},
+}
+
+var commandOrder = []string{"build", "builds", "queue", "views", "jobs", "cancel"}
+
+var errUsage = errors.New("usage error")
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("gojenkins", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	profile := global.String("profile", "", "name of the profile to use from the profiles file")
+	global.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gojenkins [-profile name] <command> [flags] [args]")
+		fmt.Fprintln(stderr, "\ncommands:")
+		for _, name := range commandOrder {
+			fmt.Fprintf(stderr, "  %v\n", commands[name].usage)
+		}
+	}
+	if err := global.Parse(args); err != nil {
+		return exitUsage
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return exitUsage
+	}
+
+	cmd, ok := commands[global.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", global.Arg(0))
+		global.Usage()
+		return exitUsage
+	}
+
+	env := &environment{stdout: stdout, stderr: stderr, profile: *profile}
+	code, err := cmd.run(ctx, env, global.Args()[1:])
+	if err == errUsage || err == flag.ErrHelp {
+		fmt.Fprintf(stderr, "usage: gojenkins %v\n", cmd.usage)
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "gojenkins: %v\n", err)
+		return exitError
+	}
+	return code
+}
+
+// parseInterleaved parses flags that may appear before or after the positional arguments,
+// e.g. "build myjob -p K=V -wait", and returns the positional arguments.
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
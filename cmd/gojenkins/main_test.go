@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venkssa/gojenkins/gojenkinstest"
+)
+
+func TestRun_BuildWaitsAndExitsWithResultCode(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddJob(gojenkinstest.Job{
+		Name:       "deploy",
+		Parameters: map[string]string{"region": "us-east-1"},
+		Runs:       []gojenkinstest.Run{{Duration: 50 * time.Millisecond, Result: "UNSTABLE", ConsoleLog: "deploying\ndone\n"}},
+	})
+
+	code, stdout, stderr := runCommand("build", "deploy", "-p", "region=eu-west-1", "-follow", "-poll", "5ms")
+
+	if code != 11 {
+		t.Errorf("Expected exit code 11 for UNSTABLE but got %v: %v", code, stderr)
+	}
+	for _, expected := range []string{"Started deploy #1", "deploying\ndone\n", "Finished deploy #1: UNSTABLE"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain %q but got %q", expected, stdout)
+		}
+	}
+	if region := srv.Builds("deploy")[0].Parameters["region"]; region != "eu-west-1" {
+		t.Errorf("Expected region parameter eu-west-1 but got %v", region)
+	}
+}
+
+func TestRun_PrintsJSON(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddJob(gojenkinstest.Job{Name: "build"})
+	srv.AddJob(gojenkinstest.Job{Name: "slow", Runs: []gojenkinstest.Run{{QueueDelay: time.Hour, Why: "Waiting for executor"}}})
+	srv.AddView("ci", "build", "slow")
+
+	runCommand("build", "slow")
+
+	code, stdout, stderr := runCommand("queue", "-json")
+	if code != exitOK {
+		t.Fatalf("Expected exit code 0 but got %v: %v", code, stderr)
+	}
+	var items []struct {
+		ID       int
+		TaskName string
+		Why      string
+	}
+	if err := json.Unmarshal([]byte(stdout), &items); err != nil || len(items) != 1 || items[0].TaskName != "slow" {
+		t.Errorf("Expected one queued item for slow but got %v, %v", stdout, err)
+	}
+
+	code, stdout, _ = runCommand("jobs", "ci", "-json")
+	if code != exitOK || strings.Join(strings.Fields(stdout), "") != `["build","slow"]` {
+		t.Errorf("Expected jobs of view ci but got %v", stdout)
+	}
+}
+
+func TestRun_JobsOfWholeInstance(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddJob(gojenkinstest.Job{Name: "build-api"})
+	srv.AddJob(gojenkinstest.Job{Name: "build-web"})
+	srv.AddJob(gojenkinstest.Job{Name: "deploy"})
+
+	code, stdout, stderr := runCommand("jobs", "-match", "^build-")
+	if code != exitOK || stdout != "build-api\nbuild-web\n" {
+		t.Errorf("Expected the matching jobs but got %v: %q %v", code, stdout, stderr)
+	}
+
+	code, stdout, _ = runCommand("jobs", "-json")
+	var jobs []struct {
+		FullName  string
+		Buildable bool
+	}
+	if err := json.Unmarshal([]byte(stdout), &jobs); code != exitOK || err != nil || len(jobs) != 3 || !jobs[2].Buildable {
+		t.Errorf("Expected all 3 jobs but got %v, %v", stdout, err)
+	}
+}
+
+func TestRun_Cancel(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddJob(gojenkinstest.Job{Name: "slow", Runs: []gojenkinstest.Run{{QueueDelay: time.Hour}}})
+	runCommand("build", "slow")
+
+	code, stdout, stderr := runCommand("cancel", "1")
+
+	if code != exitOK || !strings.Contains(stdout, "Cancelled queue item 1") {
+		t.Errorf("Expected queue item to be cancelled but got %v: %v %v", code, stdout, stderr)
+	}
+	if code, _, _ := runCommand("queue"); code != exitOK {
+		t.Errorf("Expected exit code 0 but got %v", code)
+	}
+}
+
+func TestRun_CancelBuild(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddJob(gojenkinstest.Job{Name: "deploy #eu", Runs: []gojenkinstest.Run{{Duration: time.Hour}}})
+	runCommand("build", "deploy #eu")
+
+	code, stdout, stderr := runCommand("cancel", "deploy #eu", "1")
+
+	if code != exitOK || !strings.Contains(stdout, "Aborted deploy #eu #1") {
+		t.Errorf("Expected build to be aborted but got %v: %v %v", code, stdout, stderr)
+	}
+	if builds := srv.Builds("deploy #eu"); len(builds) != 1 {
+		t.Fatalf("Expected 1 build but got %v", builds)
+	}
+	code, stdout, _ = runCommand("builds", "deploy #eu", "-json")
+	if code != exitOK || !strings.Contains(stdout, "ABORTED") {
+		t.Errorf("Expected the build to be ABORTED but got %v: %v", code, stdout)
+	}
+}
+
+func TestRun_UsageErrors(t *testing.T) {
+	for _, args := range [][]string{{}, {"unknown"}, {"build"}, {"build", "a", "-p", "novalue"}, {"cancel", "notanumber"}, {"jobs", "-match", "("}} {
+		if code, _, _ := runCommand(args...); code != exitUsage {
+			t.Errorf("Expected usage exit code for %v but got %v", args, code)
+		}
+	}
+}
+
+func newTestServer(t *testing.T) *gojenkinstest.Server {
+	srv := gojenkinstest.NewServer()
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	t.Setenv("JENKINS_URL", srv.URL)
+	t.Setenv("JENKINS_USER", "user")
+	t.Setenv("JENKINS_API_TOKEN", "token")
+	t.Setenv("JENKINS_PROFILE", "")
+	t.Setenv("JENKINS_CONFIG", filepath.Join(dir, "config.toml"))
+	t.Setenv("NETRC", filepath.Join(dir, "netrc"))
+	return srv
+}
+
+func runCommand(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_BuildOfUnknownJobFails(t *testing.T) {
+	newTestServer(t)
+
+	code, _, stderr := runCommand("build", "missing", "-wait")
+
+	if code != exitError || !strings.Contains(stderr, "404") {
+		t.Errorf("Expected exit code 1 with a 404 error but got %v: %v", code, stderr)
+	}
+}
+
+func TestRun_BuildReportsProgressWhileWaiting(t *testing.T) {
+	srv := newTestServer(t)
+	srv.AddJob(gojenkinstest.Job{
+		Name: "deploy",
+		Runs: []gojenkinstest.Run{{QueueDelay: 20 * time.Millisecond, Why: "Waiting for next available executor", Duration: 50 * time.Millisecond, Result: "SUCCESS"}},
+	})
+
+	code, _, stderr := runCommand("build", "deploy", "-wait", "-poll", "5ms")
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code 0 but got %v: %v", code, stderr)
+	}
+	for _, expected := range []string{"Waiting in queue: Waiting for next available executor", "Building #1: "} {
+		if !strings.Contains(stderr, expected) {
+			t.Errorf("Expected progress to contain %q but got %q", expected, stderr)
+		}
+	}
+}
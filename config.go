@@ -0,0 +1,360 @@
+package gojenkins
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Environment variables read by LoadConfig.
+const (
+	EnvURL      = "JENKINS_URL"
+	EnvUser     = "JENKINS_USER"
+	EnvAPIToken = "JENKINS_API_TOKEN"
+	EnvProfile  = "JENKINS_PROFILE"
+	EnvConfig   = "JENKINS_CONFIG"
+	EnvNetrc    = "NETRC"
+)
+
+// Config is the location of a jenkins instance and the credentials used to access it.
+type Config struct {
+	URL      string
+	Username string
+	APIToken string
+}
+
+// LoadConfig resolves the jenkins url and credentials from:
+//
+//   - the profiles file, which is $JENKINS_CONFIG or gojenkins/config.toml in the user config directory.
+//   - the JENKINS_URL, JENKINS_USER and JENKINS_API_TOKEN environment variables, which override the profile.
+//   - $NETRC or ~/.netrc, which is only used for credentials that are still missing for the url's host.
+//
+// The profile is selected by name. If name is empty JENKINS_PROFILE is used, and if that is not set
+// either the profile named by the top level "current" key of the profiles file.
+// The profiles file is the subset of TOML it needs: string values, either "basic" or 'literal',
+// [profiles.<name>] tables and # comments. Other TOML syntax is rejected.
+//
+//	current = "prod" # used unless a profile is selected
+//
+//	[profiles.prod]
+//	url = "https://jenkins.example.com"
+//	user = "deployer"
+//	api_token = '...'
+func LoadConfig(profile string) (Config, error) {
+	var cfg Config
+
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+	}
+	profiles, err := loadProfiles()
+	if err != nil && (profile != "" || !os.IsNotExist(err)) {
+		return Config{}, err
+	}
+	if profile == "" {
+		profile = profiles.current
+	}
+	if profile != "" {
+		p, ok := profiles.profiles[profile]
+		if !ok {
+			return Config{}, fmt.Errorf("jenkins profile %q not found", profile)
+		}
+		cfg = p
+	}
+
+	if v := os.Getenv(EnvURL); v != "" {
+		cfg.URL = v
+	}
+	if v := os.Getenv(EnvUser); v != "" {
+		cfg.Username = v
+	}
+	if v := os.Getenv(EnvAPIToken); v != "" {
+		cfg.APIToken = v
+	}
+
+	if cfg.URL == "" {
+		return Config{}, fmt.Errorf("jenkins url is not configured, set %v or configure a profile", EnvURL)
+	}
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+
+	if cfg.Username == "" || cfg.APIToken == "" {
+		if err := cfg.fillFromNetrc(); err != nil && !os.IsNotExist(err) {
+			return Config{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// NewClientFromEnv returns a Client for the jenkins instance resolved by LoadConfig("").
+func NewClientFromEnv(opts ...Option) (Client, error) {
+	return NewClientFromProfile("", opts...)
+}
+
+// NewClientFromProfile returns a Client for the jenkins instance resolved by LoadConfig(profile).
+func NewClientFromProfile(profile string, opts ...Option) (Client, error) {
+	cfg, err := LoadConfig(profile)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(cfg.URL, cfg.Username, cfg.APIToken, opts...), nil
+}
+
+type profilesFile struct {
+	current  string
+	profiles map[string]Config
+}
+
+func profilesPath() (string, error) {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gojenkins", "config.toml"), nil
+}
+
+func loadProfiles() (profilesFile, error) {
+	path, err := profilesPath()
+	if err != nil {
+		return profilesFile{}, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return profilesFile{}, err
+	}
+	defer f.Close()
+
+	profiles, err := parseProfiles(f)
+	if err != nil {
+		return profilesFile{}, fmt.Errorf("%v: %v", path, err)
+	}
+	return profiles, nil
+}
+
+func parseProfiles(r io.Reader) (profilesFile, error) {
+	profiles := profilesFile{profiles: make(map[string]Config)}
+	var section string
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line, err := stripComment(scanner.Text())
+		if err != nil {
+			return profilesFile{}, fmt.Errorf("line %v: %v", lineNumber, err)
+		}
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if strings.HasPrefix(line, "[[") {
+				return profilesFile{}, fmt.Errorf("line %v: arrays of tables are not supported", lineNumber)
+			}
+			if !strings.HasSuffix(line, "]") {
+				return profilesFile{}, fmt.Errorf("line %v: expected ] at the end of the table header", lineNumber)
+			}
+			table := strings.TrimSpace(line[1 : len(line)-1])
+			if !strings.HasPrefix(table, "profiles.") {
+				return profilesFile{}, fmt.Errorf("line %v: unsupported table %q, only [profiles.<name>] is supported", lineNumber, table)
+			}
+			name := strings.TrimSpace(strings.TrimPrefix(table, "profiles."))
+			if strings.HasPrefix(name, `"`) || strings.HasPrefix(name, "'") {
+				if name, err = parseString(name); err != nil {
+					return profilesFile{}, fmt.Errorf("line %v: profile name: %v", lineNumber, err)
+				}
+			}
+			section = name
+			if _, ok := profiles.profiles[section]; !ok {
+				profiles.profiles[section] = Config{}
+			}
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return profilesFile{}, fmt.Errorf("line %v: expected key = \"value\"", lineNumber)
+		}
+		key := strings.TrimSpace(kv[0])
+		value, err := parseString(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return profilesFile{}, fmt.Errorf("line %v: value of %v: %v", lineNumber, key, err)
+		}
+
+		if section == "" {
+			if key != "current" {
+				return profilesFile{}, fmt.Errorf("line %v: unknown key %q", lineNumber, key)
+			}
+			profiles.current = value
+			continue
+		}
+
+		cfg := profiles.profiles[section]
+		switch key {
+		case "url":
+			cfg.URL = value
+		case "user":
+			cfg.Username = value
+		case "api_token":
+			cfg.APIToken = value
+		default:
+			return profilesFile{}, fmt.Errorf("line %v: unknown key %q", lineNumber, key)
+		}
+		profiles.profiles[section] = cfg
+	}
+	return profiles, scanner.Err()
+}
+
+// stripComment removes a # comment and surrounding whitespace from a line. A # within a string is kept.
+func stripComment(line string) (string, error) {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == 0 && (strings.HasPrefix(line[i:], `"""`) || strings.HasPrefix(line[i:], "'''")):
+			return "", fmt.Errorf("multi-line strings are not supported")
+		case quote == 0 && c == '#':
+			return strings.TrimSpace(line[:i]), nil
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == '"' && c == '\\':
+			i++
+		case c == quote:
+			quote = 0
+		}
+	}
+	if quote != 0 {
+		return "", fmt.Errorf("unterminated string")
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// parseString parses a basic "string" with escapes or a literal 'string' without. Other values,
+// such as numbers or arrays, are not supported as no key of the profiles file needs them.
+func parseString(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, "["):
+		return "", fmt.Errorf("arrays are not supported")
+	case strings.HasPrefix(s, "{"):
+		return "", fmt.Errorf("inline tables are not supported")
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' && !strings.Contains(s[1:len(s)-1], "'"):
+		return s[1 : len(s)-1], nil
+	case strings.HasPrefix(s, `"`):
+		value, err := strconv.Unquote(s)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %v", s)
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("expected a quoted string but got %v", s)
+	}
+}
+
+func (cfg *Config) fillFromNetrc() error {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return err
+	}
+
+	path := os.Getenv(EnvNetrc)
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	login, password, err := netrcCredentials(f, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("%v: %v", path, err)
+	}
+	if cfg.Username == "" {
+		cfg.Username = login
+	}
+	if cfg.APIToken == "" && (cfg.Username == login || login == "") {
+		cfg.APIToken = password
+	}
+	return nil
+}
+
+// netrcCredentials returns the login and password of the netrc entry for host,
+// falling back to the default entry.
+func netrcCredentials(r io.Reader, host string) (string, string, error) {
+	var tokens []string
+	var inMacro bool
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if inMacro {
+			// A macro definition runs until the next empty line.
+			inMacro = len(fields) > 0
+			continue
+		}
+		for i, field := range fields {
+			if field == "macdef" {
+				inMacro = true
+				fields = fields[:i]
+				break
+			}
+		}
+		tokens = append(tokens, fields...)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+
+	type entry struct{ login, password string }
+	var machine, fallback *entry
+	var current *entry
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			if i+1 >= len(tokens) {
+				return "", "", errors.New("machine is missing a name")
+			}
+			i++
+			current = nil
+			if tokens[i] == host && machine == nil {
+				machine = new(entry)
+				current = machine
+			}
+		case "default":
+			current = nil
+			if fallback == nil {
+				fallback = new(entry)
+				current = fallback
+			}
+		case "login", "password", "account":
+			if i+1 >= len(tokens) {
+				return "", "", fmt.Errorf("%v is missing a value", tokens[i])
+			}
+			i++
+			if current == nil {
+				continue
+			}
+			if tokens[i-1] == "login" {
+				current.login = tokens[i]
+			} else if tokens[i-1] == "password" {
+				current.password = tokens[i]
+			}
+		}
+	}
+
+	if machine != nil {
+		return machine.login, machine.password, nil
+	}
+	if fallback != nil {
+		return fallback.login, fallback.password, nil
+	}
+	return "", "", nil
+}
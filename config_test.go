@@ -0,0 +1,199 @@
+package gojenkins
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testProfiles = `
+# Jenkins instances
+current = "prod"
+
+[profiles.prod]
+url = "https://prod.jenkins.example.com/"
+user = "deployer"
+api_token = "prod-token"
+
+[profiles."staging"]
+url = "https://staging.jenkins.example.com"
+`
+
+const testNetrc = `
+machine prod.jenkins.example.com login netrc-user password netrc-prod-password
+macdef init
+machine ignored.example.com login ignored password ignored
+
+machine staging.jenkins.example.com
+    login netrc-user
+    password netrc-staging-password
+default login anonymous password anonymous-password
+`
+
+func TestLoadConfig(t *testing.T) {
+	tests := map[string]struct {
+		profile        string
+		env            map[string]string
+		expectedConfig Config
+	}{
+		"should use the current profile": {
+			expectedConfig: Config{"https://prod.jenkins.example.com", "deployer", "prod-token"},
+		},
+		"should use the named profile and fill missing credentials from netrc": {
+			profile:        "staging",
+			expectedConfig: Config{"https://staging.jenkins.example.com", "netrc-user", "netrc-staging-password"},
+		},
+		"should use the profile from the environment": {
+			env:            map[string]string{EnvProfile: "staging"},
+			expectedConfig: Config{"https://staging.jenkins.example.com", "netrc-user", "netrc-staging-password"},
+		},
+		"should prefer environment variables over the profile": {
+			env:            map[string]string{EnvUser: "env-user", EnvAPIToken: "env-token"},
+			expectedConfig: Config{"https://prod.jenkins.example.com", "env-user", "env-token"},
+		},
+		"should prefer the url from the environment over the profile": {
+			env:            map[string]string{EnvURL: "https://other.example.com"},
+			expectedConfig: Config{"https://other.example.com", "deployer", "prod-token"},
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			setConfigTestEnv(t, testdata.env)
+
+			cfg, err := LoadConfig(testdata.profile)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if cfg != testdata.expectedConfig {
+				t.Errorf("Expected %v but got %v", testdata.expectedConfig, cfg)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_UsesNetrcDefaultEntryWithoutProfile(t *testing.T) {
+	setConfigTestEnv(t, map[string]string{EnvConfig: filepath.Join(t.TempDir(), "missing.toml"), EnvURL: "https://other.example.com"})
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	expectedConfig := Config{"https://other.example.com", "anonymous", "anonymous-password"}
+	if cfg != expectedConfig {
+		t.Errorf("Expected %v but got %v", expectedConfig, cfg)
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := map[string]struct {
+		profile       string
+		env           map[string]string
+		expectedError string
+	}{
+		"unknown profile": {
+			profile:       "missing",
+			expectedError: `jenkins profile "missing" not found`,
+		},
+		"missing url": {
+			env:           map[string]string{EnvConfig: "/does/not/exist.toml"},
+			expectedError: "jenkins url is not configured",
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			setConfigTestEnv(t, testdata.env)
+
+			if _, err := LoadConfig(testdata.profile); err == nil || !strings.Contains(err.Error(), testdata.expectedError) {
+				t.Errorf("Expected error %q but got %v", testdata.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestParseProfiles_RejectsUnknownKeys(t *testing.T) {
+	_, err := parseProfiles(strings.NewReader("[profiles.prod]\npassword = \"secret\"\n"))
+
+	if err == nil || !strings.Contains(err.Error(), `line 2: unknown key "password"`) {
+		t.Errorf("Expected unknown key error but got %v", err)
+	}
+}
+
+func TestParseProfiles_CommentsAndLiteralStrings(t *testing.T) {
+	profiles, err := parseProfiles(strings.NewReader(`
+current = 'prod' # the default
+[profiles.'prod'] # production
+url = "https://jenkins.example.com/#/" # the fragment is part of the url
+user = 'C:\deploy'
+api_token = "a\"#b"
+`))
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	expectedConfig := Config{URL: "https://jenkins.example.com/#/", Username: `C:\deploy`, APIToken: `a"#b`}
+	if profiles.current != "prod" || profiles.profiles["prod"] != expectedConfig {
+		t.Errorf("Expected current prod with %+v but got %+v", expectedConfig, profiles)
+	}
+}
+
+func TestParseProfiles_NamesUnsupportedSyntax(t *testing.T) {
+	tests := map[string]struct {
+		profiles      string
+		expectedError string
+	}{
+		"multi-line string": {
+			profiles:      "[profiles.prod]\napi_token = \"\"\"\nsecret\"\"\"\n",
+			expectedError: "line 2: multi-line strings are not supported",
+		},
+		"array": {
+			profiles:      "current = [\"prod\"]\n",
+			expectedError: "line 1: value of current: arrays are not supported",
+		},
+		"inline table": {
+			profiles:      "[profiles.prod]\nurl = { host = \"jenkins\" }\n",
+			expectedError: "line 2: value of url: inline tables are not supported",
+		},
+		"array of tables": {
+			profiles:      "[[profiles]]\n",
+			expectedError: "line 1: arrays of tables are not supported",
+		},
+		"number": {
+			profiles:      "current = 1\n",
+			expectedError: "line 1: value of current: expected a quoted string but got 1",
+		},
+		"unterminated string": {
+			profiles:      "current = 'prod\n",
+			expectedError: "line 1: unterminated string",
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			_, err := parseProfiles(strings.NewReader(testdata.profiles))
+			if err == nil || err.Error() != testdata.expectedError {
+				t.Errorf("Expected error %q but got %v", testdata.expectedError, err)
+			}
+		})
+	}
+}
+
+func setConfigTestEnv(t *testing.T, env map[string]string) {
+	dir := t.TempDir()
+	profilesPath, netrcPath := filepath.Join(dir, "config.toml"), filepath.Join(dir, "netrc")
+	if err := ioutil.WriteFile(profilesPath, []byte(testProfiles), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(netrcPath, []byte(testNetrc), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	defaults := map[string]string{EnvConfig: profilesPath, EnvNetrc: netrcPath, EnvURL: "", EnvUser: "", EnvAPIToken: "", EnvProfile: ""}
+	for key, value := range defaults {
+		if v, ok := env[key]; ok {
+			value = v
+		}
+		t.Setenv(key, value)
+	}
+}
@@ -0,0 +1,379 @@
+package gojenkins
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// GlobalCredentialDomain is the name of the domain of credentials which are not restricted to a domain.
+const GlobalCredentialDomain = "_"
+
+// CredentialScope restricts where a credential can be used.
+type CredentialScope string
+
+const (
+	// ScopeGlobal credentials can be used by jobs. It is the default if no scope is given.
+	ScopeGlobal CredentialScope = "GLOBAL"
+	// ScopeSystem credentials can only be used by jenkins itself, e.g. to connect agents.
+	ScopeSystem CredentialScope = "SYSTEM"
+)
+
+// CredentialStore is where credentials are stored, either SystemCredentialStore or the store of a folder.
+type CredentialStore struct {
+	folder string
+}
+
+// SystemCredentialStore is the store of the credentials of the jenkins controller.
+var SystemCredentialStore = CredentialStore{}
+
+// FolderCredentialStore returns the store of the credentials of a folder. Nested folders are separated by "/".
+func FolderCredentialStore(folder string) CredentialStore {
+	return CredentialStore{folder: folder}
+}
+
+func (s CredentialStore) paths(paths ...string) []string {
+	if s.folder == "" {
+		return append([]string{"credentials", "store", "system"}, paths...)
+	}
+	return jobPath(s.folder, append([]string{"credentials", "store", "folder"}, paths...)...)
+}
+
+// domainPaths returns the path of a domain of the store followed by paths. The domain is escaped as it is user-chosen.
+func (s CredentialStore) domainPaths(domain string, paths ...string) []string {
+	return s.paths(append([]string{"domain", url.PathEscape(domain)}, paths...)...)
+}
+
+// credentialPaths returns the path of a credential of the store followed by paths. The id is escaped as it is user-chosen.
+func (s CredentialStore) credentialPaths(domain, id string, paths ...string) []string {
+	return s.domainPaths(domain, append([]string{"credential", url.PathEscape(id)}, paths...)...)
+}
+
+func (s CredentialStore) route(route string) string {
+	if s.folder == "" {
+		return "credentials/store/system/" + route
+	}
+	return "job/{folder}/credentials/store/folder/" + route
+}
+
+// CredentialDomain groups credentials which are used for the same services.
+type CredentialDomain struct {
+	Name        string
+	DisplayName string
+	Description string
+}
+
+// Credential describes a stored credential. Its secret is never returned.
+type Credential struct {
+	ID          string
+	Description string
+	// Type is the name of the kind of credential, e.g. "Username with password".
+	Type string
+	// Scope is only set by ListCredentials with WithCredentialScopes. It is empty if the configuration
+	// of the credential could not be read, e.g. without the Credentials/Update permission.
+	Scope CredentialScope
+}
+
+// CredentialSpec is a credential to create or update, one of UsernamePasswordCredential,
+// SecretTextCredential, SSHKeyCredential or FileCredential.
+type CredentialSpec interface {
+	credentialID() string
+	marshalCredential() ([]byte, error)
+}
+
+// UsernamePasswordCredential is a username with a password.
+type UsernamePasswordCredential struct {
+	ID          string
+	Description string
+	Scope       CredentialScope
+	Username    string
+	Password    string
+}
+
+// SecretTextCredential is a secret such as an API token.
+type SecretTextCredential struct {
+	ID          string
+	Description string
+	Scope       CredentialScope
+	Secret      string
+}
+
+// SSHKeyCredential is a username with a SSH private key.
+type SSHKeyCredential struct {
+	ID          string
+	Description string
+	Scope       CredentialScope
+	Username    string
+	// PrivateKey is the PEM encoded private key.
+	PrivateKey string
+	// Passphrase decrypts the private key, empty if it is not encrypted.
+	Passphrase string
+}
+
+// FileCredential is a secret file.
+type FileCredential struct {
+	ID          string
+	Description string
+	Scope       CredentialScope
+	FileName    string
+	Content     []byte
+}
+
+// credentialXML are the elements common to all credentials.
+type credentialXML struct {
+	Scope       CredentialScope `xml:"scope"`
+	ID          string          `xml:"id"`
+	Description string          `xml:"description"`
+}
+
+func newCredentialXML(id, description string, scope CredentialScope) credentialXML {
+	if scope == "" {
+		scope = ScopeGlobal
+	}
+	return credentialXML{Scope: scope, ID: id, Description: description}
+}
+
+func (c UsernamePasswordCredential) credentialID() string { return c.ID }
+
+func (c UsernamePasswordCredential) marshalCredential() ([]byte, error) {
+	return xml.Marshal(struct {
+		XMLName xml.Name `xml:"com.cloudbees.plugins.credentials.impl.UsernamePasswordCredentialsImpl"`
+		credentialXML
+		Username string `xml:"username"`
+		Password string `xml:"password"`
+	}{credentialXML: newCredentialXML(c.ID, c.Description, c.Scope), Username: c.Username, Password: c.Password})
+}
+
+func (c SecretTextCredential) credentialID() string { return c.ID }
+
+func (c SecretTextCredential) marshalCredential() ([]byte, error) {
+	return xml.Marshal(struct {
+		XMLName xml.Name `xml:"org.jenkinsci.plugins.plaincredentials.impl.StringCredentialsImpl"`
+		credentialXML
+		Secret string `xml:"secret"`
+	}{credentialXML: newCredentialXML(c.ID, c.Description, c.Scope), Secret: c.Secret})
+}
+
+func (c SSHKeyCredential) credentialID() string { return c.ID }
+
+func (c SSHKeyCredential) marshalCredential() ([]byte, error) {
+	type privateKeySource struct {
+		Class      string `xml:"class,attr"`
+		PrivateKey string `xml:"privateKey"`
+	}
+	return xml.Marshal(struct {
+		XMLName xml.Name `xml:"com.cloudbees.jenkins.plugins.sshcredentials.impl.BasicSSHUserPrivateKey"`
+		credentialXML
+		Username         string           `xml:"username"`
+		Passphrase       string           `xml:"passphrase"`
+		PrivateKeySource privateKeySource `xml:"privateKeySource"`
+	}{
+		credentialXML: newCredentialXML(c.ID, c.Description, c.Scope),
+		Username:      c.Username,
+		Passphrase:    c.Passphrase,
+		PrivateKeySource: privateKeySource{
+			Class:      "com.cloudbees.jenkins.plugins.sshcredentials.impl.BasicSSHUserPrivateKey$DirectEntryPrivateKeySource",
+			PrivateKey: c.PrivateKey,
+		},
+	})
+}
+
+func (c FileCredential) credentialID() string { return c.ID }
+
+func (c FileCredential) marshalCredential() ([]byte, error) {
+	return xml.Marshal(struct {
+		XMLName xml.Name `xml:"org.jenkinsci.plugins.plaincredentials.impl.FileCredentialsImpl"`
+		credentialXML
+		FileName string `xml:"fileName"`
+		// SecretBytes are encrypted by jenkins when they are not already.
+		SecretBytes string `xml:"secretBytes"`
+	}{
+		credentialXML: newCredentialXML(c.ID, c.Description, c.Scope),
+		FileName:      c.FileName,
+		SecretBytes:   base64.StdEncoding.EncodeToString(c.Content),
+	})
+}
+
+// CredentialsAPI is the interface to manage credentials stored by the Jenkins Credentials plugin.
+// The methods of a Client return an error matching ErrUnsupported if the plugin is missing.
+type CredentialsAPI interface {
+	// ListCredentialDomains returns the domains of the store.
+	ListCredentialDomains(ctx context.Context, store CredentialStore) ([]CredentialDomain, error)
+
+	// ListCredentials returns the credentials of a domain of the store, e.g. GlobalCredentialDomain.
+	// Their scope is only returned with WithCredentialScopes.
+	ListCredentials(ctx context.Context, store CredentialStore, domain string, opts ...ListCredentialsOption) ([]Credential, error)
+
+	// CreateCredential adds a credential to a domain of the store.
+	CreateCredential(ctx context.Context, store CredentialStore, domain string, c CredentialSpec) error
+
+	// UpdateCredential replaces the credential with the same ID, e.g. to rotate its password.
+	UpdateCredential(ctx context.Context, store CredentialStore, domain string, c CredentialSpec) error
+
+	// DeleteCredential removes the credential from a domain of the store.
+	DeleteCredential(ctx context.Context, store CredentialStore, domain, id string) error
+}
+
+// ListCredentialsOption configures CredentialsAPI.ListCredentials.
+type ListCredentialsOption func(*listCredentialsConfig)
+
+type listCredentialsConfig struct {
+	scopes bool
+}
+
+// WithCredentialScopes configures ListCredentials to look up the scope of every credential. Jenkins
+// only returns it as part of the configuration of a credential, which costs an additional request per
+// credential. It is looked up best-effort: credentials whose configuration cannot be read are
+// returned without a scope, and no more lookups are made once jenkins denies one.
+func WithCredentialScopes() ListCredentialsOption {
+	return func(c *listCredentialsConfig) {
+		c.scopes = true
+	}
+}
+
+func NewCredentialsAPI(u URLBuilder, r Requestor) credentialsAPI {
+	return credentialsAPI{URLBuilder: u, requestor: r}
+}
+
+type credentialsAPI struct {
+	URLBuilder
+	requestor Requestor
+	// capabilities, if set, tells a missing folder or credential from a missing credentials plugin.
+	capabilities *capabilitiesAPI
+}
+
+// unsupported wraps a 404 with ErrUnsupported if the credentials plugin is missing.
+func (c credentialsAPI) unsupported(ctx context.Context, err error) error {
+	return c.capabilities.missingAsUnsupported(ctx, err, "credentials", hasCredentials)
+}
+
+func (c credentialsAPI) ListCredentialDomains(ctx context.Context, store CredentialStore) ([]CredentialDomain, error) {
+	var storeResponse struct {
+		Domains map[string]struct {
+			DisplayName string
+			Description string
+		}
+	}
+
+	resp := c.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    c.URLBuilder.JSONEndpoint(store.paths()...),
+		Route:  store.route("api/json"),
+		Query:  url.Values{"depth": []string{"1"}},
+	})
+
+	if err := resp.VerifyAndDecode(JsonDecoder(&storeResponse)); err != nil {
+		return nil, c.unsupported(ctx, err)
+	}
+
+	var domains []CredentialDomain
+	for name, domain := range storeResponse.Domains {
+		domains = append(domains, CredentialDomain{Name: name, DisplayName: domain.DisplayName, Description: domain.Description})
+	}
+	sort.Slice(domains, func(i, j int) bool { return domains[i].Name < domains[j].Name })
+	return domains, nil
+}
+
+func (c credentialsAPI) ListCredentials(ctx context.Context, store CredentialStore, domain string, opts ...ListCredentialsOption) ([]Credential, error) {
+	var cfg listCredentialsConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var domainResponse struct {
+		Credentials []struct {
+			ID          string
+			Description string
+			TypeName    string
+		}
+	}
+
+	resp := c.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    c.URLBuilder.JSONEndpoint(store.domainPaths(domain)...),
+		Route:  store.route("domain/{domain}/api/json"),
+		Query:  url.Values{"tree": []string{"credentials[id,description,typeName]"}},
+	})
+
+	if err := resp.VerifyAndDecode(JsonDecoder(&domainResponse)); err != nil {
+		return nil, c.unsupported(ctx, err)
+	}
+
+	var credentials []Credential
+	lookupScope := cfg.scopes
+	for _, credential := range domainResponse.Credentials {
+		var scope CredentialScope
+		if lookupScope {
+			var err error
+			scope, err = c.credentialScope(ctx, store, domain, credential.ID)
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			// Reading the configuration needs more permissions than listing, jenkins denies it for every credential.
+			lookupScope = !hasStatusCode(err, http.StatusUnauthorized, http.StatusForbidden)
+		}
+		credentials = append(credentials, Credential{
+			ID:          credential.ID,
+			Description: credential.Description,
+			Type:        credential.TypeName,
+			Scope:       scope,
+		})
+	}
+	return credentials, nil
+}
+
+// credentialScope reads the scope of a credential from its configuration, which jenkins does not list.
+func (c credentialsAPI) credentialScope(ctx context.Context, store CredentialStore, domain, id string) (CredentialScope, error) {
+	var config struct {
+		Scope CredentialScope `xml:"scope"`
+	}
+	err := c.requestor.
+		Do(ctx, Request{
+			Method: http.MethodGet,
+			URL:    c.URLBuilder.Endpoint(store.credentialPaths(domain, id, "config.xml")...),
+			Route:  store.route("domain/{domain}/credential/{id}/config.xml"),
+		}).
+		VerifyAndDecode(XMLDecoder(&config))
+	if err != nil {
+		return "", err
+	}
+	return config.Scope, nil
+}
+
+func (c credentialsAPI) CreateCredential(ctx context.Context, store CredentialStore, domain string, credential CredentialSpec) error {
+	return c.postCredential(ctx, store.domainPaths(domain, "createCredentials"), store.route("domain/{domain}/createCredentials"), credential)
+}
+
+func (c credentialsAPI) UpdateCredential(ctx context.Context, store CredentialStore, domain string, credential CredentialSpec) error {
+	paths := store.credentialPaths(domain, credential.credentialID(), "config.xml")
+	return c.postCredential(ctx, paths, store.route("domain/{domain}/credential/{id}/config.xml"), credential)
+}
+
+func (c credentialsAPI) postCredential(ctx context.Context, paths []string, route string, credential CredentialSpec) error {
+	data, err := credential.marshalCredential()
+	if err != nil {
+		return err
+	}
+
+	resp := c.requestor.Do(ctx, Request{
+		Method:      http.MethodPost,
+		URL:         c.URLBuilder.Endpoint(paths...),
+		Route:       route,
+		ContentType: ContentTypeXML,
+		Body:        bytes.NewReader(data),
+	})
+	return c.unsupported(ctx, resp.VerifyAndDecode(NoOpDecoder))
+}
+
+func (c credentialsAPI) DeleteCredential(ctx context.Context, store CredentialStore, domain, id string) error {
+	resp := c.requestor.Do(ctx, Request{
+		Method: http.MethodPost,
+		URL:    c.URLBuilder.Endpoint(store.credentialPaths(domain, id, "doDelete")...),
+		Route:  store.route("domain/{domain}/credential/{id}/doDelete"),
+	})
+	return c.unsupported(ctx, resp.VerifyAndDecode(NoOpDecoder, StatusOKOrFoundVerifier))
+}
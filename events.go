@@ -0,0 +1,394 @@
+package gojenkins
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errEventsUnavailable is returned when the SSE Gateway plugin cannot be used and callers should poll instead.
+var errEventsUnavailable = errors.New("jenkins sse gateway is unavailable")
+
+// JobEvent is an event published by jenkins on the job channel of the SSE Gateway plugin.
+type JobEvent struct {
+	Event     string `json:"jenkins_event"`
+	JobName   string `json:"job_name"`
+	ObjectURL string `json:"jenkins_object_url"`
+	QueueID   string `json:"job_run_queueId"`
+	Status    string `json:"job_run_status"`
+}
+
+// EventSubscriber waits for builds using the event stream of the SSE Gateway plugin (/sse-gateway)
+// instead of polling. All waits share a single event stream, which is opened on demand and closed
+// once nobody is waiting.
+//
+// If the plugin is not installed, or the stream fails, waits fall back to polling like
+// QueueAPI.WaitUntilBuildIsQueued and JobAPI.WaitUntilBuildIsComplete do.
+type EventSubscriber struct {
+	urlBuilder URLBuilder
+	requestor  Requestor
+	jobs       jobAPI
+	queue      queueAPI
+	// capabilities, if set, avoids connecting to a missing SSE Gateway plugin.
+	capabilities *capabilitiesAPI
+
+	mu          sync.Mutex
+	unsupported bool
+	stream      *eventStream
+	// connecting is closed once the stream being opened by one of the waits is connected or failed.
+	connecting chan struct{}
+	batchID    int
+}
+
+type eventStream struct {
+	resp          *Response
+	cancelFn      context.CancelFunc
+	done          chan struct{}
+	subscriptions map[*eventSubscription]struct{}
+}
+
+type eventSubscription struct {
+	stream *eventStream
+	match  func(JobEvent) bool
+	ch     chan struct{}
+}
+
+func NewEventSubscriber(u URLBuilder, r Requestor) *EventSubscriber {
+	return &EventSubscriber{
+		urlBuilder: u,
+		requestor:  r,
+		jobs:       NewJobAPI(u, r),
+		queue:      NewQueueAPI(u, r),
+	}
+}
+
+// WaitUntilBuildIsQueued has the same semantics as QueueAPI.WaitUntilBuildIsQueued but waits for
+// queue events. The queue item is still checked every retryAfter in case an event is missed.
+func (s *EventSubscriber) WaitUntilBuildIsQueued(ctx context.Context, id QueueID, retryAfter time.Duration, opts ...WaitOption) (QueueItem, error) {
+	cfg := newWaitConfig(opts)
+	ctx, cancelFn := setTimeoutIfNotSet(ctx, DefaultWaitForBuildToBeQueuedTimeout)
+	defer cancelFn()
+
+	queueID := fmt.Sprint(id)
+	sub, done, err := s.subscribe(ctx, func(e JobEvent) bool {
+		return e.QueueID == queueID
+	})
+	if err != nil {
+		return s.queue.WaitUntilBuildIsQueued(ctx, id, retryAfter, opts...)
+	}
+	defer s.unsubscribe(sub)
+
+	var queueItem QueueItem
+	err = s.waitForEvents(ctx, "WaitUntilBuildIsQueued", sub, done, retryAfter, func() (bool, error) {
+		var err error
+		queueItem, err = s.queue.pollQueueItem(ctx, id, cfg)
+		return queueItem.Number == 0, err
+	})
+	if err == errEventsUnavailable {
+		return s.queue.WaitUntilBuildIsQueued(ctx, id, retryAfter, opts...)
+	}
+	return queueItem, err
+}
+
+// WaitUntilBuildIsComplete has the same semantics as JobAPI.WaitUntilBuildIsComplete but waits for
+// the build's job_run_ended event. The build is still checked every retryAfter in case an event is
+// missed, so retryAfter can be much longer than when polling. Progress is only reported when the
+// build is checked.
+func (s *EventSubscriber) WaitUntilBuildIsComplete(ctx context.Context, item QueueItem, retryAfter time.Duration, opts ...WaitOption) (BuildInfo, error) {
+	cfg := newWaitConfig(opts)
+	ctx, cancelFn := setTimeoutIfNotSet(ctx, DefaultWaitForBuildToBeCompletedTimeout)
+	defer cancelFn()
+
+	buildPath := eventObjectPath(item.URL)
+	sub, done, err := s.subscribe(ctx, func(e JobEvent) bool {
+		objectPath := eventObjectPath(e.ObjectURL)
+		return objectPath != "" && (buildPath == objectPath || strings.HasSuffix(buildPath, "/"+objectPath))
+	})
+	if err != nil {
+		return s.jobs.WaitUntilBuildIsComplete(ctx, item, retryAfter, opts...)
+	}
+	defer s.unsubscribe(sub)
+
+	var buildInfo BuildInfo
+	err = s.waitForEvents(ctx, "WaitUntilBuildIsComplete", sub, done, retryAfter, func() (bool, error) {
+		var err error
+		buildInfo, err = s.jobs.pollBuild(ctx, item, cfg)
+		return buildInfo.Building, err
+	})
+	if err == errEventsUnavailable {
+		return s.jobs.WaitUntilBuildIsComplete(ctx, item, retryAfter, opts...)
+	}
+	return buildInfo, err
+}
+
+// waitForEvents calls fn once and then every time a matching event arrives, or retryAfter passed without one,
+// until fn returns false or an error. The first call catches up with events that happened before the
+// subscription was made, the periodic calls with events that were dropped. Every call is reported
+// as a poll iteration of the operation, like retryUntilFalseOrError does when polling.
+func (s *EventSubscriber) waitForEvents(ctx context.Context, operation string, sub *eventSubscription, done <-chan struct{}, retryAfter time.Duration, fn func() (bool, error)) error {
+	for iteration := 1; ; iteration++ {
+		pollIteration(ctx, s.requestor, operation, iteration)
+		shouldWait, err := fn()
+		if err != nil && !isTransient(err) {
+			return err
+		}
+		if err == nil && !shouldWait {
+			return nil
+		}
+
+		afterChan := time.After(retryAfter)
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("%w: last error: %w", ctx.Err(), err)
+			}
+			return ctx.Err()
+		case <-done:
+			return errEventsUnavailable
+		case <-sub.ch:
+		case <-afterChan:
+		}
+	}
+}
+
+// subscribe adds a subscription to the event stream, opening it if needed. The stream is opened outside
+// of s.mu so that other waits are not blocked by it. They wait for it instead, or open it themselves
+// if the wait opening it gave up.
+func (s *EventSubscriber) subscribe(ctx context.Context, match func(JobEvent) bool) (*eventSubscription, <-chan struct{}, error) {
+	s.mu.Lock()
+	for s.connecting != nil {
+		connecting := s.connecting
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-connecting:
+		}
+		s.mu.Lock()
+	}
+	if s.unsupported {
+		s.mu.Unlock()
+		return nil, nil, errEventsUnavailable
+	}
+	if s.stream != nil {
+		defer s.mu.Unlock()
+		return s.stream.subscribe(match), s.stream.done, nil
+	}
+	connecting := make(chan struct{})
+	s.connecting = connecting
+	s.batchID++
+	batchID := s.batchID
+	s.mu.Unlock()
+
+	var stream *eventStream
+	err := errEventsUnavailable
+	if !s.capabilities.lacks(ctx, hasSSEGateway) {
+		stream, err = s.connect(ctx, batchID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connecting = nil
+	close(connecting)
+	if err != nil {
+		if err == errEventsUnavailable || hasStatusCode(err, http.StatusNotFound) {
+			s.unsupported = true
+		}
+		return nil, nil, err
+	}
+	s.stream = stream
+	sub := stream.subscribe(match)
+	go s.readEvents(stream)
+	return sub, stream.done, nil
+}
+
+// subscribe adds a subscription to the stream. It is called with s.mu of the EventSubscriber held.
+func (stream *eventStream) subscribe(match func(JobEvent) bool) *eventSubscription {
+	sub := &eventSubscription{stream: stream, match: match, ch: make(chan struct{}, 1)}
+	stream.subscriptions[sub] = struct{}{}
+	return sub
+}
+
+func (s *EventSubscriber) unsubscribe(sub *eventSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(sub.stream.subscriptions, sub)
+	if len(sub.stream.subscriptions) == 0 {
+		sub.stream.cancelFn()
+		if s.stream == sub.stream {
+			s.stream = nil
+		}
+	}
+}
+
+// connect opens the event stream and subscribes to the job channel. Its events are read once readEvents is started.
+func (s *EventSubscriber) connect(ctx context.Context, batchID int) (*eventStream, error) {
+	clientID, err := newClientID()
+	if err != nil {
+		return nil, err
+	}
+
+	// The gateway ties the dispatcher to the http session, so its cookie has to be sent on every request.
+	var cookies []*http.Cookie
+	sessionCookies := func(resp *http.Response) error {
+		cookies = resp.Cookies()
+		return nil
+	}
+	err = s.requestor.
+		Do(ctx, Request{
+			Method: http.MethodGet,
+			URL:    s.urlBuilder.Endpoint("sse-gateway", "connect"),
+			Route:  "sse-gateway/connect",
+			Query:  url.Values{"clientId": []string{clientID}},
+		}).
+		VerifyAndDecode(NoOpDecoder, StatusOKVerifier, sessionCookies)
+	if err != nil {
+		return nil, err
+	}
+	// Only the name and value of a cookie are sent back, all of them in a single header.
+	pairs := make([]string, 0, len(cookies))
+	for _, cookie := range cookies {
+		pairs = append(pairs, (&http.Cookie{Name: cookie.Name, Value: cookie.Value}).String())
+	}
+	header := make(http.Header)
+	if len(pairs) > 0 {
+		header.Set("Cookie", strings.Join(pairs, "; "))
+	}
+
+	// The stream outlives the wait that opened it, so it must not be bound to its context.
+	streamCtx, cancelFn := context.WithCancel(context.Background())
+	resp := s.requestor.Do(streamCtx, Request{
+		Method: http.MethodGet,
+		URL:    s.urlBuilder.Endpoint("sse-gateway", "listen", clientID),
+		Route:  "sse-gateway/listen/{clientId}",
+		Header: header,
+	})
+	if resp.Err() != nil || resp.HTTPResponse().StatusCode != http.StatusOK {
+		err := resp.VerifyAndDecode(NoOpDecoder)
+		cancelFn()
+		return nil, err
+	}
+
+	configuration, _ := json.Marshal(map[string]interface{}{
+		"dispatcherId": clientID,
+		"subscribe":    []map[string]string{{"jenkins_channel": "job"}},
+		"unsubscribe":  []map[string]string{},
+	})
+	err = s.requestor.
+		Do(ctx, Request{
+			Method:      http.MethodPost,
+			URL:         s.urlBuilder.Endpoint("sse-gateway", "configure"),
+			Route:       "sse-gateway/configure",
+			Query:       url.Values{"batchId": []string{fmt.Sprint(batchID)}},
+			Header:      header,
+			ContentType: ContentTypeJSON,
+			Body:        bytes.NewReader(configuration),
+		}).
+		VerifyAndDecode(NoOpDecoder)
+	if err != nil {
+		// Cancel before discarding, the stream would otherwise be drained until jenkins closes it.
+		cancelFn()
+		resp.discard()
+		return nil, err
+	}
+
+	return &eventStream{
+		resp:          resp,
+		cancelFn:      cancelFn,
+		done:          make(chan struct{}),
+		subscriptions: make(map[*eventSubscription]struct{}),
+	}, nil
+}
+
+// readEvents dispatches the events of the stream to matching subscriptions until the stream ends.
+func (s *EventSubscriber) readEvents(stream *eventStream) {
+	defer func() {
+		stream.cancelFn()
+		stream.resp.discard()
+		s.mu.Lock()
+		if s.stream == stream {
+			s.stream = nil
+		}
+		s.mu.Unlock()
+		close(stream.done)
+	}()
+
+	scanner := bufio.NewScanner(stream.resp.HTTPResponse().Body)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+
+		var event JobEvent
+		err := json.Unmarshal([]byte(data.String()), &event)
+		data.Reset()
+		if err != nil {
+			continue
+		}
+
+		s.mu.Lock()
+		for sub := range stream.subscriptions {
+			if sub.match(event) {
+				select {
+				case sub.ch <- struct{}{}:
+				default:
+				}
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// eventJobAPI is a JobAPI that waits for builds to complete using an EventSubscriber.
+type eventJobAPI struct {
+	JobAPI
+	subscriber *EventSubscriber
+}
+
+func (j eventJobAPI) WaitUntilBuildIsComplete(ctx context.Context, item QueueItem, retryAfter time.Duration, opts ...WaitOption) (BuildInfo, error) {
+	return j.subscriber.WaitUntilBuildIsComplete(ctx, item, retryAfter, opts...)
+}
+
+// eventQueueAPI is a QueueAPI that waits for builds to be queued using an EventSubscriber.
+type eventQueueAPI struct {
+	QueueAPI
+	subscriber *EventSubscriber
+}
+
+func (q eventQueueAPI) WaitUntilBuildIsQueued(ctx context.Context, id QueueID, retryAfter time.Duration, opts ...WaitOption) (QueueItem, error) {
+	return q.subscriber.WaitUntilBuildIsQueued(ctx, id, retryAfter, opts...)
+}
+
+// eventObjectPath returns the path of a build url without its leading and trailing slashes,
+// e.g. jenkins/job/test/2 for http://host/jenkins/job/test/2/.
+func eventObjectPath(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		rawURL = u.Path
+	}
+	return strings.Trim(rawURL, "/")
+}
+
+func newClientID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "gojenkins-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,329 @@
+package gojenkins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEventSubscriber_WaitUntilBuildIsComplete(t *testing.T) {
+	gateway := newSSEGatewayTestServer(t)
+	defer gateway.Close()
+
+	var completed int32
+	gateway.mux.HandleFunc("/job/Test/2/api/json", func(resp http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt32(&completed) == 1 {
+			fmt.Fprint(resp, buildCompleteResponse)
+			return
+		}
+		fmt.Fprint(resp, buildInProgressResponse)
+	})
+	go func() {
+		<-gateway.configured
+		atomic.StoreInt32(&completed, 1)
+		gateway.events <- `{"jenkins_event":"job_run_ended","jenkins_object_url":"job/Test/2/"}`
+	}()
+
+	subscriber := NewEventSubscriber(URLBuilder(gateway.URL), BasicAuthRequestor("", ""))
+	item := QueueItem{Number: 2, URL: gateway.URL + "/job/Test/2"}
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Second)
+	defer cancelFn()
+
+	// retryAfter is longer than the timeout, so the build can only complete through the event.
+	info, err := subscriber.WaitUntilBuildIsComplete(ctx, item, time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if info.Result != "SUCCESS" {
+		t.Errorf("Expected SUCCESS but got %v", info.Result)
+	}
+	if !strings.Contains(gateway.configureBody, `"jenkins_channel":"job"`) {
+		t.Errorf("Expected a subscription to the job channel but got %v", gateway.configureBody)
+	}
+}
+
+func TestEventSubscriber_SendsSessionCookies(t *testing.T) {
+	gateway := newSSEGatewayTestServer(t)
+	defer gateway.Close()
+	gateway.mux.HandleFunc("/job/Test/2/api/json", stringResponseHandleFunc(buildCompleteResponse))
+
+	subscriber := NewEventSubscriber(URLBuilder(gateway.URL), BasicAuthRequestor("", ""))
+	item := QueueItem{Number: 2, URL: gateway.URL + "/job/Test/2"}
+	if _, err := subscriber.WaitUntilBuildIsComplete(context.TODO(), item, time.Hour); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	// Jenkins sets its cookies with attributes, only their names and values are sent back.
+	expectedCookies := []string{"JSESSIONID.4f2a=test-session; route=node-1"}
+	if !reflect.DeepEqual(gateway.configureCookies, expectedCookies) {
+		t.Errorf("Expected the session cookies %q when configuring but got %q", expectedCookies, gateway.configureCookies)
+	}
+}
+
+func TestEventSubscriber_WaitUntilBuildIsQueued(t *testing.T) {
+	gateway := newSSEGatewayTestServer(t)
+	defer gateway.Close()
+
+	var started int32
+	gateway.mux.HandleFunc("/queue/item/3/api/json", func(resp http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt32(&started) == 1 {
+			fmt.Fprint(resp, queueItemWithExecutable)
+			return
+		}
+		fmt.Fprint(resp, "{}")
+	})
+	go func() {
+		<-gateway.configured
+		gateway.events <- `{"jenkins_event":"job_run_queue_left","job_run_queueId":"4"}`
+		atomic.StoreInt32(&started, 1)
+		gateway.events <- `{"jenkins_event":"job_run_started","job_run_queueId":"3"}`
+	}()
+
+	subscriber := NewEventSubscriber(URLBuilder(gateway.URL), BasicAuthRequestor("", ""))
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Second)
+	defer cancelFn()
+
+	item, err := subscriber.WaitUntilBuildIsQueued(ctx, 3, time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if item.Number != 2 {
+		t.Errorf("Expected build number 2 but got %v", item.Number)
+	}
+}
+
+func TestEventSubscriber_FallsBackToPollingWithoutGateway(t *testing.T) {
+	var connects int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse-gateway/connect", func(resp http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&connects, 1)
+		http.NotFound(resp, req)
+	})
+	mux.HandleFunc("/job/Test/2/api/json", responseCountCheckingHandlerFunc(t,
+		buildInProgressResponse, buildCompleteResponse, buildCompleteResponse))
+	srvr := httptest.NewServer(mux)
+	defer srvr.Close()
+
+	subscriber := NewEventSubscriber(URLBuilder(srvr.URL), BasicAuthRequestor("", ""))
+	item := QueueItem{Number: 2, URL: srvr.URL + "/job/Test/2"}
+	for i := 0; i < 2; i++ {
+		ctx, cancelFn := context.WithTimeout(context.Background(), time.Second)
+		info, err := subscriber.WaitUntilBuildIsComplete(ctx, item, time.Millisecond)
+		cancelFn()
+		if err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		if info.Result != "SUCCESS" {
+			t.Errorf("Expected SUCCESS but got %v", info.Result)
+		}
+	}
+	if connects := atomic.LoadInt32(&connects); connects != 1 {
+		t.Errorf("Expected a missing gateway to be remembered but it was connected to %v times", connects)
+	}
+}
+
+func TestEventSubscriber_FallsBackToPollingWhenStreamEnds(t *testing.T) {
+	gateway := newSSEGatewayTestServer(t)
+	defer gateway.Close()
+	gateway.mux.HandleFunc("/job/Test/2/api/json", responseCountCheckingHandlerFunc(t,
+		buildInProgressResponse, buildInProgressResponse, buildCompleteResponse))
+	go func() {
+		<-gateway.configured
+		close(gateway.events)
+	}()
+
+	subscriber := NewEventSubscriber(URLBuilder(gateway.URL), BasicAuthRequestor("", ""))
+	item := QueueItem{Number: 2, URL: gateway.URL + "/job/Test/2"}
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Second)
+	defer cancelFn()
+
+	info, err := subscriber.WaitUntilBuildIsComplete(ctx, item, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if info.Result != "SUCCESS" {
+		t.Errorf("Expected SUCCESS but got %v", info.Result)
+	}
+}
+
+func TestEventSubscriber_PollsWhenEventsAreMissed(t *testing.T) {
+	gateway := newSSEGatewayTestServer(t)
+	defer gateway.Close()
+	gateway.mux.HandleFunc("/job/Test/2/api/json", responseCountCheckingHandlerFunc(t,
+		buildInProgressResponse, buildInProgressResponse, buildCompleteResponse))
+
+	observer := &recordingObserver{}
+	subscriber := NewEventSubscriber(URLBuilder(gateway.URL), BasicAuthRequestor("", "").WithObserver(observer))
+	item := QueueItem{Number: 2, URL: gateway.URL + "/job/Test/2"}
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Second)
+	defer cancelFn()
+
+	// No event is sent, so the build can only complete by polling.
+	info, err := subscriber.WaitUntilBuildIsComplete(ctx, item, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if info.Result != "SUCCESS" {
+		t.Errorf("Expected SUCCESS but got %v", info.Result)
+	}
+	expectedPolls := []string{"WaitUntilBuildIsComplete 1", "WaitUntilBuildIsComplete 2", "WaitUntilBuildIsComplete 3"}
+	if !reflect.DeepEqual(observer.polls, expectedPolls) {
+		t.Errorf("Expected polls %v but got %v", expectedPolls, observer.polls)
+	}
+}
+
+func TestEventSubscriber_WrapsLastErrorWhenContextIsDone(t *testing.T) {
+	gateway := newSSEGatewayTestServer(t)
+	defer gateway.Close()
+	gateway.mux.HandleFunc("/job/Test/2/api/json", func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	subscriber := NewEventSubscriber(URLBuilder(gateway.URL), BasicAuthRequestor("", "").WithRetryPolicy(RetryPolicy{}))
+	item := QueueItem{Number: 2, URL: gateway.URL + "/job/Test/2"}
+	ctx, cancelFn := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelFn()
+
+	// retryAfter is longer than the timeout, so the context is done while waiting after the failure.
+	_, err := subscriber.WaitUntilBuildIsComplete(ctx, item, time.Hour)
+	if !errors.Is(err, context.DeadlineExceeded) || !hasStatusCode(err, http.StatusServiceUnavailable) {
+		t.Errorf("Expected the deadline and the last error but got %v", err)
+	}
+}
+
+func TestEventSubscriber_SlowConnectDoesNotBlockOtherWaits(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse-gateway/connect", func(resp http.ResponseWriter, req *http.Request) {
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+		http.NotFound(resp, req)
+	})
+	srvr := httptest.NewServer(mux)
+	defer srvr.Close()
+
+	subscriber := NewEventSubscriber(URLBuilder(srvr.URL), BasicAuthRequestor("", ""))
+	item := QueueItem{Number: 2, URL: srvr.URL + "/job/Test/2"}
+	slowCtx, cancelSlowFn := context.WithCancel(context.Background())
+	defer cancelSlowFn()
+	go subscriber.WaitUntilBuildIsComplete(slowCtx, item, time.Hour)
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelFn()
+	done := make(chan error, 1)
+	go func() {
+		_, err := subscriber.WaitUntilBuildIsComplete(ctx, item, time.Hour)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected %v but got %v", context.DeadlineExceeded, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the wait to time out while another wait was connecting")
+	}
+}
+
+func TestNewClient_WithEventSubscription(t *testing.T) {
+	gateway := newSSEGatewayTestServer(t)
+	defer gateway.Close()
+	gateway.mux.HandleFunc("/job/Test/2/api/json", stringResponseHandleFunc(buildCompleteResponse))
+
+	client := NewClient(gateway.URL, "", "", WithEventSubscription())
+	item := QueueItem{Number: 2, URL: gateway.URL + "/job/Test/2"}
+	if _, err := client.WaitUntilBuildIsComplete(context.TODO(), item, time.Hour); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	select {
+	case <-gateway.configured:
+	default:
+		t.Errorf("Expected the client to subscribe to events")
+	}
+}
+
+func TestEventObjectPath(t *testing.T) {
+	tests := map[string]string{
+		"http://testurl.com/jenkins/job/Test/2/": "jenkins/job/Test/2",
+		"job/Test/2/":                            "job/Test/2",
+		"/job/Test/2":                            "job/Test/2",
+		"":                                       "",
+	}
+	for url, expectedPath := range tests {
+		if path := eventObjectPath(url); path != expectedPath {
+			t.Errorf("Expected %q for %q but got %q", expectedPath, url, path)
+		}
+	}
+}
+
+type sseGatewayTestServer struct {
+	*httptest.Server
+	mux *http.ServeMux
+
+	// events are written to the stream until the channel is closed.
+	events chan string
+	// configured is closed once the client subscribed.
+	configured chan struct{}
+	// configureCookies are the Cookie headers sent when configuring.
+	configureCookies []string
+	configureBody    string
+}
+
+func newSSEGatewayTestServer(t *testing.T) *sseGatewayTestServer {
+	s := &sseGatewayTestServer{
+		mux:        http.NewServeMux(),
+		events:     make(chan string),
+		configured: make(chan struct{}),
+	}
+	s.mux.HandleFunc("/sse-gateway/connect", func(resp http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("clientId") == "" {
+			t.Errorf("Expected a clientId when connecting")
+		}
+		http.SetCookie(resp, &http.Cookie{Name: "JSESSIONID.4f2a", Value: "test-session", Path: "/", HttpOnly: true})
+		http.SetCookie(resp, &http.Cookie{Name: "route", Value: "node-1", Path: "/"})
+		fmt.Fprint(resp, `{"status":"OK"}`)
+	})
+	s.mux.HandleFunc("/sse-gateway/listen/", func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Content-Type", "text/event-stream")
+		resp.WriteHeader(http.StatusOK)
+		resp.(http.Flusher).Flush()
+		for {
+			select {
+			case <-req.Context().Done():
+				return
+			case event, ok := <-s.events:
+				if !ok {
+					return
+				}
+				fmt.Fprintf(resp, "event: job\nid: 1\ndata: %v\n\n", event)
+				resp.(http.Flusher).Flush()
+			}
+		}
+	})
+	s.mux.HandleFunc("/sse-gateway/configure", func(resp http.ResponseWriter, req *http.Request) {
+		var configuration map[string]interface{}
+		if err := json.NewDecoder(req.Body).Decode(&configuration); err != nil {
+			t.Errorf("Expected a json configuration but got %v", err)
+		}
+		body, _ := json.Marshal(configuration)
+		s.configureBody = string(body)
+		s.configureCookies = req.Header.Values("Cookie")
+		fmt.Fprint(resp, `{"status":"OK"}`)
+		close(s.configured)
+	})
+	s.Server = httptest.NewServer(s.mux)
+	return s
+}
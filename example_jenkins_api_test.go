@@ -43,6 +43,26 @@ func ExampleNewClient() {
 	// Output: 1 SUCCESS
 }
 
+func ExampleClient_Run() {
+	mockJenkins := newMockJenkinsServer("testjob")
+	defer mockJenkins.Close()
+
+	client := gojenkins.NewClient(mockJenkins.URL, "username", "apikey")
+
+	// Context with 10 Minute timeout after which the build is aborted and deadline exceeded error is returned.
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancelFn()
+
+	// Schedules a testjob build and waits for it to be queued and complete running.
+	result, err := client.Run(ctx, "testjob", nil, gojenkins.WithPollInterval(30*time.Second), gojenkins.WithCancelOnDone())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(result.Build.Number, result.Build.Result)
+	// Output: 1 SUCCESS
+}
+
 type mockJenkinsServer struct {
 	*httptest.Server
 	jobName string
@@ -75,7 +95,7 @@ func (m *mockJenkinsServer) waitUntilBuildIsQueuedHandlerFunc(resp http.Response
 		}`,
 		m.URL, m.jobName)
 
-	fmt.Fprintf(resp, queueItemStr)
+	fmt.Fprint(resp, queueItemStr)
 }
 
 func (m *mockJenkinsServer) waitUntilBuildIsCompleteHandlerFunc(resp http.ResponseWriter, _ *http.Request) {
@@ -89,5 +109,5 @@ func (m *mockJenkinsServer) waitUntilBuildIsCompleteHandlerFunc(resp http.Respon
 		}`,
 		m.URL)
 
-	fmt.Fprintf(resp, buildCompleteStr)
+	fmt.Fprint(resp, buildCompleteStr)
 }
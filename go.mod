@@ -0,0 +1,3 @@
+module github.com/venkssa/gojenkins
+
+go 1.21
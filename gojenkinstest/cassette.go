@@ -0,0 +1,254 @@
+package gojenkinstest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/venkssa/gojenkins"
+)
+
+// Mode selects whether a Cassette records or replays interactions.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette file without sending requests.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests and records every interaction to be saved to the cassette file.
+	ModeRecord
+)
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the part of a request that is recorded. Replayed requests are matched on
+// Method, Path and Query.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a recorded response.
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Cassette records interactions with a real jenkins to a fixture file and replays them in tests.
+//
+// A Cassette is used as a gojenkins.Middleware:
+//
+//	cassette, err := gojenkinstest.NewCassette("testdata/schedule.json", gojenkinstest.ModeReplay)
+//	client := gojenkins.NewClient(url, user, token, gojenkins.WithMiddleware(cassette.Middleware))
+//
+// Credentials, cookies and crumbs are scrubbed from recorded headers. Scrub can be set to remove
+// anything else, e.g. secrets in request bodies, before the cassette is saved.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+
+	// Scrub is called for every recorded interaction before it is stored.
+	Scrub func(*Interaction) `json:"-"`
+
+	path   string
+	mode   Mode
+	mu     sync.Mutex
+	played map[string]int
+}
+
+// NewCassette returns a cassette backed by the file at path. In ModeReplay the file must exist.
+func NewCassette(path string, mode Mode) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode, played: make(map[string]int)}
+	if mode == ModeRecord {
+		return c, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return c, nil
+}
+
+// Save writes the recorded interactions to the cassette file.
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, append(data, '\n'), 0644)
+}
+
+// Middleware records or replays every request passing through it depending on the mode of the cassette.
+func (c *Cassette) Middleware(next gojenkins.Requestor) gojenkins.Requestor {
+	return gojenkins.RequestorFunc(func(ctx context.Context, req gojenkins.Request) *gojenkins.Response {
+		if c.mode == ModeRecord {
+			return c.record(ctx, next, req)
+		}
+		return c.replay(req)
+	})
+}
+
+func (c *Cassette) record(ctx context.Context, next gojenkins.Requestor, req gojenkins.Request) *gojenkins.Response {
+	recorded, err := recordRequest(&req)
+	if err != nil {
+		return gojenkins.NewResponse(nil, err)
+	}
+
+	resp := next.Do(ctx, req)
+	httpResp := resp.HTTPResponse()
+	if httpResp == nil {
+		return resp
+	}
+
+	body, err := ioutil.ReadAll(httpResp.Body)
+	httpResp.Body.Close()
+	if err != nil {
+		return gojenkins.NewResponse(nil, err)
+	}
+	httpResp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	interaction := Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode: httpResp.StatusCode,
+			Header:     scrubHeader(httpResp.Header),
+			Body:       string(body),
+		},
+	}
+	if c.Scrub != nil {
+		c.Scrub(&interaction)
+	}
+
+	c.mu.Lock()
+	c.Interactions = append(c.Interactions, interaction)
+	c.mu.Unlock()
+	return resp
+}
+
+// replay returns the recorded responses of matching requests in the order they were recorded.
+// The last one is repeated once all have been played, which lets polling loops replay.
+func (c *Cassette) replay(req gojenkins.Request) *gojenkins.Response {
+	recorded, err := recordRequest(&req)
+	if err != nil {
+		return gojenkins.NewResponse(nil, err)
+	}
+	key := matchKey(recorded)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var matches []Interaction
+	for _, interaction := range c.Interactions {
+		if matchKey(interaction.Request) == key {
+			matches = append(matches, interaction)
+		}
+	}
+	if len(matches) == 0 {
+		return gojenkins.NewResponse(nil, fmt.Errorf("cassette %v has no interaction for %v", c.path, key))
+	}
+
+	idx := c.played[key]
+	if idx >= len(matches) {
+		idx = len(matches) - 1
+	}
+	c.played[key] = idx + 1
+
+	recordedResp := matches[idx].Response
+	return gojenkins.NewResponse(&http.Response{
+		StatusCode: recordedResp.StatusCode,
+		Status:     fmt.Sprintf("%d %s", recordedResp.StatusCode, http.StatusText(recordedResp.StatusCode)),
+		Header:     recordedResp.Header,
+		Body:       ioutil.NopCloser(strings.NewReader(recordedResp.Body)),
+	}, nil)
+}
+
+// recordRequest captures req, replacing its body so that it can still be sent.
+func recordRequest(req *gojenkins.Request) (RecordedRequest, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return RecordedRequest{}, err
+	}
+	query := u.Query()
+	for name, values := range req.Query {
+		query[name] = append(query[name], values...)
+	}
+
+	recorded := RecordedRequest{
+		Method: req.Method,
+		Path:   u.Path,
+		Query:  query.Encode(),
+		Header: scrubHeader(req.Header),
+	}
+	if recorded.Method == "" {
+		recorded.Method = http.MethodGet
+	}
+
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return RecordedRequest{}, err
+		}
+		req.Body = bytes.NewReader(body)
+		recorded.Body = string(body)
+	}
+	return recorded, nil
+}
+
+func matchKey(req RecordedRequest) string {
+	// Jenkins returns urls with a trailing slash which the client appends paths to.
+	path := strings.Replace(req.Path, "//", "/", -1)
+	if req.Query == "" {
+		return req.Method + " " + path
+	}
+	return req.Method + " " + path + "?" + req.Query
+}
+
+const scrubbed = "SCRUBBED"
+
+func scrubHeader(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	scrubbedHeader := make(http.Header, len(h))
+	for name, values := range h {
+		lower := strings.ToLower(name)
+		switch {
+		case lower == "authorization", lower == "proxy-authorization", lower == "cookie", lower == "set-cookie",
+			strings.Contains(lower, "crumb"), strings.Contains(lower, "token"):
+			values = []string{scrubbed}
+		}
+		scrubbedHeader[name] = values
+	}
+	return scrubbedHeader
+}
+
+// Exists reports whether the cassette file at path exists, e.g. to record on the first run:
+//
+//	mode := gojenkinstest.ModeReplay
+//	if !gojenkinstest.Exists(path) {
+//		mode = gojenkinstest.ModeRecord
+//	}
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
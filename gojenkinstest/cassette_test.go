@@ -0,0 +1,93 @@
+package gojenkinstest_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venkssa/gojenkins"
+	"github.com/venkssa/gojenkins/gojenkinstest"
+)
+
+func TestCassette_RecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	srv, clock := newTestServer()
+	srv.AddJob(gojenkinstest.Job{Name: "deploy", Runs: []gojenkinstest.Run{{Duration: time.Minute, Result: "UNSTABLE"}}})
+	recorder, err := gojenkinstest.NewCassette(path, gojenkinstest.ModeRecord)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	recorded := scheduleAndWait(t, gojenkins.NewClient(srv.URL, "user", "secret-token",
+		gojenkins.WithMiddleware(crumbMiddleware, recorder.Middleware)), clock)
+	srv.Close()
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	if strings.Contains(string(data), "secret-crumb") {
+		t.Errorf("Expected crumb to be scrubbed from the cassette but got %s", data)
+	}
+
+	player, err := gojenkinstest.NewCassette(path, gojenkinstest.ModeReplay)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	replayed := scheduleAndWait(t, gojenkins.NewClient("http://127.0.0.1:1", "", "",
+		gojenkins.WithMiddleware(player.Middleware)), nil)
+
+	if replayed != recorded || replayed.Result != "UNSTABLE" {
+		t.Errorf("Expected replayed build %v to equal recorded build %v", replayed, recorded)
+	}
+}
+
+func TestCassette_ReplayFailsForUnknownRequests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	ioutil.WriteFile(path, []byte(`{"interactions": []}`), 0644)
+	player, err := gojenkinstest.NewCassette(path, gojenkinstest.ModeReplay)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	client := gojenkins.NewClient("http://127.0.0.1:1", "", "", gojenkins.WithMiddleware(player.Middleware))
+	_, err = client.QueueStats(context.Background())
+
+	if err == nil || !strings.Contains(err.Error(), "no interaction for GET /queue/api/json") {
+		t.Errorf("Expected missing interaction error but got %v", err)
+	}
+}
+
+func scheduleAndWait(t *testing.T, client gojenkins.Client, clock *fakeClock) gojenkins.BuildInfo {
+	ctx := context.Background()
+	queueID, err := client.ScheduleBuild(ctx, "deploy", nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	item, err := client.WaitUntilBuildIsQueued(ctx, queueID, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if info, err := client.BuildInfo(ctx, item); err != nil || !info.Building {
+		t.Fatalf("Expected build to be in progress but got %v, %v", info, err)
+	}
+	if clock != nil {
+		clock.Advance(time.Minute)
+	}
+	info, err := client.WaitUntilBuildIsComplete(ctx, item, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	return info
+}
+
+func crumbMiddleware(next gojenkins.Requestor) gojenkins.Requestor {
+	return gojenkins.RequestorFunc(func(ctx context.Context, req gojenkins.Request) *gojenkins.Response {
+		req.Header = http.Header{"Jenkins-Crumb": []string{"secret-crumb"}}
+		return next.Do(ctx, req)
+	})
+}
@@ -0,0 +1,553 @@
+// Package gojenkinstest provides an in-memory fake Jenkins server to test code built on gojenkins.
+//
+// Jobs are defined with scripted runs. Scheduling a build creates a queue item which turns into a
+// build after the run's QueueDelay, and the build completes with the run's Result after its Duration.
+package gojenkinstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version is the jenkins version reported by the fake server in the X-Jenkins header.
+const Version = "2.426.3"
+
+// Job defines a job known to the fake server.
+type Job struct {
+	Name string
+
+	// Parameters are the default values of the job's parameters.
+	// Values passed when scheduling a build override them, unknown parameters are ignored like jenkins does.
+	Parameters map[string]string
+
+	// Runs script the builds of the job in order. The last run is repeated once all have been used.
+	// A build succeeds immediately if there are no runs.
+	Runs []Run
+}
+
+// Run scripts the lifecycle of a single build.
+type Run struct {
+	// QueueDelay is how long the build waits in the queue before it starts.
+	QueueDelay time.Duration
+	// Why is the reason reported by the queue item while the build is waiting.
+	// Defaults to "Waiting for next available executor".
+	Why string
+	// Duration is how long the build runs.
+	Duration time.Duration
+	// Result of the build once it completes. Defaults to SUCCESS.
+	Result string
+	// ConsoleLog is the output of the build. It is revealed proportionally to the elapsed time while building.
+	ConsoleLog string
+	// Artifacts maps the relative path of an artifact to its content.
+	Artifacts map[string]string
+}
+
+// Build is a build scheduled on the fake server.
+type Build struct {
+	JobName    string
+	Number     int
+	QueueID    int
+	Parameters map[string]string
+	Run        Run
+
+	queuedAt  time.Time
+	cancelled bool
+	abortedAt time.Time
+}
+
+// Server is a fake Jenkins server. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	now         func() time.Time
+	jobs        map[string]*job
+	views       map[string][]string
+	builds      []*Build
+	nextQueueID int
+}
+
+type job struct {
+	Job
+	builds []*Build
+}
+
+// NewServer starts a fake Jenkins server. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		now:         time.Now,
+		jobs:        make(map[string]*job),
+		views:       make(map[string][]string),
+		nextQueueID: 1,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// SetClock replaces the clock used to progress queue items and builds, e.g. to control time in tests.
+func (s *Server) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// AddJob defines a job, replacing any existing job with the same name.
+func (s *Server) AddJob(j Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[j.Name] = &job{Job: j}
+}
+
+// AddView defines a view containing the given jobs.
+func (s *Server) AddView(name string, jobNames ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.views[name] = append([]string(nil), jobNames...)
+}
+
+// Builds returns a copy of the builds scheduled for the job, oldest first.
+func (s *Server) Builds(jobName string) []Build {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[jobName]
+	if !ok {
+		return nil
+	}
+	builds := make([]Build, 0, len(j.builds))
+	for _, b := range j.builds {
+		builds = append(builds, *b)
+	}
+	return builds
+}
+
+func (s *Server) serveHTTP(resp http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp.Header().Set("X-Jenkins", Version)
+
+	var parts []string
+	for _, part := range strings.Split(req.URL.Path, "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) > 0 && parts[len(parts)-1] == "json" && len(parts) > 1 && parts[len(parts)-2] == "api" {
+		parts = parts[:len(parts)-2]
+	}
+
+	switch {
+	case len(parts) == 0:
+		s.writeJSON(resp, s.rootJSON())
+	case parts[0] == "queue":
+		s.serveQueue(resp, req, parts[1:])
+	case parts[0] == "view" && len(parts) == 2:
+		s.serveView(resp, req, parts[1])
+	case parts[0] == "job" && len(parts) >= 2:
+		s.serveJob(resp, req, parts[1], parts[2:])
+	default:
+		http.NotFound(resp, req)
+	}
+}
+
+func (s *Server) serveQueue(resp http.ResponseWriter, req *http.Request, parts []string) {
+	switch {
+	case len(parts) == 0:
+		items := []interface{}{}
+		for _, b := range s.builds {
+			if s.isQueued(b) {
+				items = append(items, s.queueItemJSON(b))
+			}
+		}
+		s.writeJSON(resp, map[string]interface{}{"items": items})
+	case len(parts) == 2 && parts[0] == "item":
+		b := s.buildByQueueID(parts[1])
+		if b == nil {
+			http.NotFound(resp, req)
+			return
+		}
+		s.writeJSON(resp, s.queueItemJSON(b))
+	case len(parts) == 1 && parts[0] == "cancelItem" && req.Method == http.MethodPost:
+		b := s.buildByQueueID(req.URL.Query().Get("id"))
+		if b == nil {
+			http.NotFound(resp, req)
+			return
+		}
+		if s.isQueued(b) {
+			b.cancelled = true
+		}
+		resp.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(resp, req)
+	}
+}
+
+func (s *Server) serveView(resp http.ResponseWriter, req *http.Request, name string) {
+	jobNames, ok := s.views[name]
+	if !ok {
+		http.NotFound(resp, req)
+		return
+	}
+	jobs := []interface{}{}
+	for _, jobName := range jobNames {
+		if j, ok := s.jobs[jobName]; ok {
+			jobs = append(jobs, s.jobSummaryJSON(j))
+		}
+	}
+	s.writeJSON(resp, map[string]interface{}{
+		"name": name,
+		"url":  fmt.Sprintf("%v/view/%v/", s.URL, url.PathEscape(name)),
+		"jobs": jobs,
+	})
+}
+
+func (s *Server) serveJob(resp http.ResponseWriter, req *http.Request, name string, parts []string) {
+	j, ok := s.jobs[name]
+	if !ok {
+		http.NotFound(resp, req)
+		return
+	}
+
+	if len(parts) == 0 {
+		s.writeJSON(resp, s.jobJSON(j))
+		return
+	}
+
+	switch parts[0] {
+	case "build", "buildWithParameters":
+		if req.Method != http.MethodPost {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if err := req.ParseForm(); err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b := s.schedule(j, req.Form)
+		resp.Header().Set("Location", fmt.Sprintf("%v/queue/item/%v/", s.URL, b.QueueID))
+		resp.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	number, err := strconv.Atoi(parts[0])
+	if err != nil || number < 1 || number > len(j.builds) {
+		http.NotFound(resp, req)
+		return
+	}
+	b := j.builds[number-1]
+	if !s.hasStarted(b) {
+		http.NotFound(resp, req)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		s.writeJSON(resp, s.buildJSON(b))
+	case parts[1] == "consoleText":
+		fmt.Fprint(resp, s.consoleLog(b))
+	case parts[1] == "logText" && len(parts) == 3 && parts[2] == "progressiveText":
+		s.serveProgressiveText(resp, req, b)
+	case parts[1] == "artifact" && len(parts) > 2:
+		content, ok := b.Run.Artifacts[strings.Join(parts[2:], "/")]
+		if !ok || s.isBuilding(b) {
+			http.NotFound(resp, req)
+			return
+		}
+		fmt.Fprint(resp, content)
+	case parts[1] == "stop" && req.Method == http.MethodPost:
+		if s.isBuilding(b) {
+			b.abortedAt = s.now()
+		}
+		resp.WriteHeader(http.StatusOK)
+	default:
+		http.NotFound(resp, req)
+	}
+}
+
+func (s *Server) serveProgressiveText(resp http.ResponseWriter, req *http.Request, b *Build) {
+	log := s.consoleLog(b)
+	start, _ := strconv.Atoi(req.URL.Query().Get("start"))
+	if start < 0 || start > len(log) {
+		start = len(log)
+	}
+	resp.Header().Set("X-Text-Size", strconv.Itoa(len(log)))
+	if s.isBuilding(b) {
+		resp.Header().Set("X-More-Data", "true")
+	}
+	fmt.Fprint(resp, log[start:])
+}
+
+func (s *Server) schedule(j *job, form url.Values) *Build {
+	params := make(map[string]string)
+	for name, value := range j.Parameters {
+		params[name] = value
+		if v, ok := form[name]; ok && len(v) > 0 {
+			params[name] = v[0]
+		}
+	}
+
+	run := Run{}
+	if len(j.Runs) > 0 {
+		run = j.Runs[len(j.Runs)-1]
+		if len(j.builds) < len(j.Runs) {
+			run = j.Runs[len(j.builds)]
+		}
+	}
+
+	b := &Build{
+		JobName:    j.Name,
+		Number:     len(j.builds) + 1,
+		QueueID:    s.nextQueueID,
+		Parameters: params,
+		Run:        run,
+		queuedAt:   s.now(),
+	}
+	s.nextQueueID++
+	j.builds = append(j.builds, b)
+	s.builds = append(s.builds, b)
+	return b
+}
+
+func (s *Server) buildByQueueID(id string) *Build {
+	for _, b := range s.builds {
+		if strconv.Itoa(b.QueueID) == id {
+			return b
+		}
+	}
+	return nil
+}
+
+func (s *Server) startedAt(b *Build) time.Time {
+	return b.queuedAt.Add(b.Run.QueueDelay)
+}
+
+func (s *Server) hasStarted(b *Build) bool {
+	return !b.cancelled && !s.now().Before(s.startedAt(b))
+}
+
+func (s *Server) isQueued(b *Build) bool {
+	return !b.cancelled && !s.hasStarted(b)
+}
+
+func (s *Server) isBuilding(b *Build) bool {
+	return s.hasStarted(b) && b.abortedAt.IsZero() && s.now().Before(s.startedAt(b).Add(b.Run.Duration))
+}
+
+func (s *Server) result(b *Build) interface{} {
+	switch {
+	case !b.abortedAt.IsZero():
+		return "ABORTED"
+	case s.isBuilding(b):
+		return nil
+	case b.Run.Result == "":
+		return "SUCCESS"
+	}
+	return b.Run.Result
+}
+
+func (s *Server) consoleLog(b *Build) string {
+	log := b.Run.ConsoleLog
+	if !s.isBuilding(b) || b.Run.Duration <= 0 {
+		return log
+	}
+	elapsed := s.now().Sub(s.startedAt(b))
+	return log[:int(float64(len(log))*float64(elapsed)/float64(b.Run.Duration))]
+}
+
+func (s *Server) jobURL(name string) string {
+	return fmt.Sprintf("%v/job/%v/", s.URL, url.PathEscape(name))
+}
+
+func (s *Server) buildURL(b *Build) string {
+	return fmt.Sprintf("%v%v/", s.jobURL(b.JobName), b.Number)
+}
+
+func (s *Server) color(j *job) string {
+	if len(j.builds) == 0 {
+		return "notbuilt"
+	}
+	var color string
+	var building bool
+	for i := len(j.builds) - 1; i >= 0 && color == ""; i-- {
+		b := j.builds[i]
+		if !s.hasStarted(b) {
+			continue
+		}
+		if s.isBuilding(b) {
+			building = true
+			continue
+		}
+		switch s.result(b) {
+		case "SUCCESS":
+			color = "blue"
+		case "UNSTABLE":
+			color = "yellow"
+		case "ABORTED":
+			color = "aborted"
+		default:
+			color = "red"
+		}
+	}
+	if color == "" {
+		color = "notbuilt"
+	}
+	if building {
+		color += "_anime"
+	}
+	return color
+}
+
+func (s *Server) rootJSON() map[string]interface{} {
+	names := make([]string, 0, len(s.jobs))
+	for name := range s.jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	jobs := []interface{}{}
+	for _, name := range names {
+		jobs = append(jobs, s.jobSummaryJSON(s.jobs[name]))
+	}
+
+	viewNames := make([]string, 0, len(s.views))
+	for name := range s.views {
+		viewNames = append(viewNames, name)
+	}
+	sort.Strings(viewNames)
+	views := []interface{}{}
+	for _, name := range viewNames {
+		views = append(views, map[string]interface{}{
+			"name": name,
+			"url":  fmt.Sprintf("%v/view/%v/", s.URL, url.PathEscape(name)),
+		})
+	}
+
+	return map[string]interface{}{"jobs": jobs, "views": views, "url": s.URL + "/"}
+}
+
+func (s *Server) jobSummaryJSON(j *job) map[string]interface{} {
+	return map[string]interface{}{
+		"_class":    "hudson.model.FreeStyleProject",
+		"name":      j.Name,
+		"fullName":  j.Name,
+		"url":       s.jobURL(j.Name),
+		"color":     s.color(j),
+		"buildable": true,
+	}
+}
+
+func (s *Server) jobJSON(j *job) map[string]interface{} {
+	info := s.jobSummaryJSON(j)
+
+	builds := []interface{}{}
+	for i := len(j.builds) - 1; i >= 0; i-- {
+		if s.hasStarted(j.builds[i]) {
+			builds = append(builds, s.buildJSON(j.builds[i]))
+		}
+	}
+	info["builds"] = builds
+
+	// Permalinks point to the most recent build matching them, like jenkins a successful build may be unstable.
+	permalinks := map[string]func(result interface{}) bool{
+		"lastBuild":             func(result interface{}) bool { return true },
+		"lastCompletedBuild":    func(result interface{}) bool { return result != nil },
+		"lastSuccessfulBuild":   func(result interface{}) bool { return result == "SUCCESS" || result == "UNSTABLE" },
+		"lastStableBuild":       func(result interface{}) bool { return result == "SUCCESS" },
+		"lastFailedBuild":       func(result interface{}) bool { return result == "FAILURE" },
+		"lastUnsuccessfulBuild": func(result interface{}) bool { return result != nil && result != "SUCCESS" },
+	}
+	for permalink, matches := range permalinks {
+		info[permalink] = nil
+		for i := len(j.builds) - 1; i >= 0; i-- {
+			if b := j.builds[i]; s.hasStarted(b) && matches(s.result(b)) {
+				info[permalink] = s.buildJSON(b)
+				break
+			}
+		}
+	}
+	return info
+}
+
+func (s *Server) buildJSON(b *Build) map[string]interface{} {
+	artifactPaths := make([]string, 0, len(b.Run.Artifacts))
+	for path := range b.Run.Artifacts {
+		artifactPaths = append(artifactPaths, path)
+	}
+	sort.Strings(artifactPaths)
+	artifacts := []interface{}{}
+	if !s.isBuilding(b) {
+		for _, path := range artifactPaths {
+			artifacts = append(artifacts, map[string]interface{}{
+				"fileName":     path[strings.LastIndex(path, "/")+1:],
+				"relativePath": path,
+			})
+		}
+	}
+
+	params := []interface{}{}
+	for name, value := range b.Parameters {
+		params = append(params, map[string]interface{}{"name": name, "value": value})
+	}
+
+	duration := b.Run.Duration
+	if s.isBuilding(b) {
+		duration = 0
+	} else if !b.abortedAt.IsZero() {
+		duration = b.abortedAt.Sub(s.startedAt(b))
+	}
+
+	return map[string]interface{}{
+		"_class":            "hudson.model.FreeStyleBuild",
+		"number":            b.Number,
+		"queueId":           b.QueueID,
+		"url":               s.buildURL(b),
+		"building":          s.isBuilding(b),
+		"result":            s.result(b),
+		"timestamp":         s.startedAt(b).UnixNano() / int64(time.Millisecond),
+		"duration":          duration.Nanoseconds() / int64(time.Millisecond),
+		"estimatedDuration": b.Run.Duration.Nanoseconds() / int64(time.Millisecond),
+		"artifacts":         artifacts,
+		"actions":           []interface{}{map[string]interface{}{"parameters": params}},
+	}
+}
+
+func (s *Server) queueItemJSON(b *Build) map[string]interface{} {
+	why := b.Run.Why
+	if why == "" {
+		why = "Waiting for next available executor"
+	}
+	item := map[string]interface{}{
+		"id":           b.QueueID,
+		"url":          fmt.Sprintf("queue/item/%v/", b.QueueID),
+		"inQueueSince": b.queuedAt.UnixNano() / int64(time.Millisecond),
+		"cancelled":    b.cancelled,
+		"task": map[string]interface{}{
+			"name":  b.JobName,
+			"url":   s.jobURL(b.JobName),
+			"color": s.color(s.jobs[b.JobName]),
+		},
+		"why":        nil,
+		"executable": nil,
+	}
+	if s.isQueued(b) {
+		item["why"] = why
+	}
+	if s.hasStarted(b) {
+		item["executable"] = map[string]interface{}{"number": b.Number, "url": s.buildURL(b)}
+	}
+	return item
+}
+
+func (s *Server) writeJSON(resp http.ResponseWriter, v interface{}) {
+	resp.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(resp).Encode(v)
+}
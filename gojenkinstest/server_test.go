@@ -0,0 +1,132 @@
+package gojenkinstest_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/venkssa/gojenkins"
+	"github.com/venkssa/gojenkins/gojenkinstest"
+)
+
+func TestServer_BuildLifecycle(t *testing.T) {
+	srv, clock := newTestServer()
+	defer srv.Close()
+	srv.AddJob(gojenkinstest.Job{
+		Name:       "deploy",
+		Parameters: map[string]string{"region": "us-east-1", "dryRun": "true"},
+		Runs: []gojenkinstest.Run{
+			{QueueDelay: time.Minute, Duration: 10 * time.Minute, Result: "FAILURE", ConsoleLog: "deploying\nfailed\n"},
+			{Duration: time.Minute, Artifacts: map[string]string{"out/version.txt": "1.2.3"}},
+		},
+	})
+	client := gojenkins.NewClient(srv.URL, "user", "token")
+	ctx := context.Background()
+
+	queueID, err := client.ScheduleBuild(ctx, "deploy", url.Values{"region": []string{"eu-west-1"}, "unknown": []string{"x"}})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if stats, _ := client.QueueStats(ctx); stats.Length != 1 {
+		t.Errorf("Expected 1 queued build but got %v", stats)
+	}
+
+	clock.Advance(time.Minute)
+	item, err := client.WaitUntilBuildIsQueued(ctx, queueID, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if info, _ := client.BuildInfo(ctx, item); !info.Building || info.Number != 1 {
+		t.Errorf("Expected build 1 to be building but got %v", info)
+	}
+
+	clock.Advance(10 * time.Minute)
+	info, err := client.WaitUntilBuildIsComplete(ctx, item, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if info.Result != "FAILURE" || gojenkins.QueueID(info.QueueID) != queueID {
+		t.Errorf("Expected the scripted FAILURE result but got %v", info)
+	}
+	if failed, err := client.LastBuild(ctx, "deploy", gojenkins.LastFailedBuild); err != nil || failed.Number != 1 {
+		t.Errorf("Expected build 1 to be the last failed build but got %v, %v", failed, err)
+	}
+	if _, err := client.LastBuild(ctx, "deploy", gojenkins.LastSuccessfulBuild); !errors.Is(err, gojenkins.ErrBuildNotFound) {
+		t.Errorf("Expected no successful build but got %v", err)
+	}
+
+	expectedParams := map[string]string{"region": "eu-west-1", "dryRun": "true"}
+	if params := srv.Builds("deploy")[0].Parameters; !reflect.DeepEqual(expectedParams, params) {
+		t.Errorf("Expected parameters %v but got %v", expectedParams, params)
+	}
+	if log := get(t, item.URL+"/consoleText"); log != "deploying\nfailed\n" {
+		t.Errorf("Expected console log but got %q", log)
+	}
+}
+
+func TestServer_ArtifactsAndViews(t *testing.T) {
+	srv, clock := newTestServer()
+	defer srv.Close()
+	srv.AddJob(gojenkinstest.Job{Name: "build", Runs: []gojenkinstest.Run{{Artifacts: map[string]string{"out/version.txt": "1.2.3"}}}})
+	srv.AddJob(gojenkinstest.Job{Name: "test"})
+	srv.AddView("ci", "build", "test")
+	client := gojenkins.NewClient(srv.URL, "user", "token")
+	ctx := context.Background()
+
+	names, err := client.ListJobNames(ctx, "ci")
+	if err != nil || !reflect.DeepEqual([]string{"build", "test"}, names) {
+		t.Errorf("Expected jobs of view ci but got %v, %v", names, err)
+	}
+
+	if _, err := client.ScheduleBuild(ctx, "build", nil); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	clock.Advance(time.Second)
+	builds, err := client.GetBuilds(ctx, "build", 0, 5)
+	if err != nil || len(builds) != 1 || builds[0].Result != "SUCCESS" {
+		t.Fatalf("Expected one successful build but got %v, %v", builds, err)
+	}
+	if artifact := get(t, builds[0].URL+"artifact/out/version.txt"); artifact != "1.2.3" {
+		t.Errorf("Expected artifact content 1.2.3 but got %q", artifact)
+	}
+}
+
+func newTestServer() (*gojenkinstest.Server, *fakeClock) {
+	srv := gojenkinstest.NewServer()
+	clock := &fakeClock{now: time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)}
+	srv.SetClock(clock.Now)
+	return srv, clock
+}
+
+func get(t *testing.T, url string) string {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	return string(body)
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
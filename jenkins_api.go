@@ -3,6 +3,9 @@ package gojenkins
 
 import (
 	"context"
+	"crypto/tls"
+	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -11,19 +14,149 @@ type Client interface {
 	JobAPI
 	QueueAPI
 	ViewAPI
+	PluginAPI
+	SystemAPI
+	UserAPI
+	MultibranchAPI
+	PipelineAPI
+	CredentialsAPI
+	CapabilitiesAPI
+
+	// Run schedules a build of the job and waits until it completes. See RunOption for how to
+	// configure polling, timeouts and cancellation.
+	Run(ctx context.Context, jobName string, params url.Values, opts ...RunOption) (RunResult, error)
+
+	// RunBatch runs many builds concurrently and waits for all of them. See BatchOption for how to
+	// limit concurrency and fail fast.
+	RunBatch(ctx context.Context, runs []BatchRun, opts ...BatchOption) (BatchResult, error)
 }
 
-func NewClient(baseURL, username, apiKey string) Client {
+type client struct {
+	JobAPI
+	QueueAPI
+	ViewAPI
+	PluginAPI
+	SystemAPI
+	UserAPI
+	MultibranchAPI
+	PipelineAPI
+	CredentialsAPI
+	CapabilitiesAPI
+}
+
+// Option configures the Client returned by NewClient.
+type Option func(*clientConfig)
+
+type clientConfig struct {
+	requestor HTTPRequestor
+	events    bool
+}
+
+// WithRetryPolicy configures how the client retries requests that failed with a transient error.
+// DefaultRetryPolicy is used if this option is not provided.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *clientConfig) {
+		c.requestor = c.requestor.WithRetryPolicy(p)
+	}
+}
+
+// WithLogger configures the client to trace requests and report errors to l.
+// The client does not log anything if this option is not provided.
+func WithLogger(l Logger) Option {
+	return func(c *clientConfig) {
+		c.requestor = c.requestor.WithLogger(l)
+	}
+}
+
+// WithObserver configures the client to notify o about every request and poll, e.g. to record traces and metrics.
+func WithObserver(o Observer) Option {
+	return func(c *clientConfig) {
+		c.requestor = c.requestor.WithObserver(o)
+	}
+}
+
+// WithMiddleware configures the client to pass every request through the given middlewares.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *clientConfig) {
+		c.requestor = c.requestor.WithMiddleware(middlewares...)
+	}
+}
+
+// WithAuthenticator configures the client to authenticate using a instead of basic auth with
+// the username and apiKey passed to NewClient, e.g. with BearerTokenAuthenticator or OAuth2Authenticator.
+func WithAuthenticator(a Authenticator) Option {
+	return func(c *clientConfig) {
+		c.requestor = c.requestor.WithAuthenticator(a)
+	}
+}
+
+// WithHTTPClient configures the client to send requests using client instead of http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *clientConfig) {
+		c.requestor = c.requestor.WithHTTPClient(client)
+	}
+}
+
+// WithTLSConfig configures the transport used by the client with cfg.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *clientConfig) {
+		c.requestor = c.requestor.WithTLSConfig(cfg)
+	}
+}
+
+// WithClientCertificate configures the client to present the certificate in certFile and keyFile for mutual TLS.
+// Rotated certificates are reloaded from disk.
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(c *clientConfig) {
+		c.requestor = c.requestor.WithClientCertificate(certFile, keyFile)
+	}
+}
+
+// WithEventSubscription configures the client to wait for builds to be queued and completed using
+// the events of the SSE Gateway plugin instead of polling. The client falls back to polling if
+// the plugin is not installed. See EventSubscriber.
+func WithEventSubscription() Option {
+	return func(c *clientConfig) {
+		c.events = true
+	}
+}
+
+func NewClient(baseURL, username, apiKey string, opts ...Option) Client {
 	urlBuilder := URLBuilder(baseURL)
-	requestor := BasicAuthRequestor(username, apiKey)
-	return struct {
-		JobAPI
-		QueueAPI
-		ViewAPI
-	}{
-		JobAPI:   NewJobAPI(urlBuilder, requestor),
-		QueueAPI: NewQueueAPI(urlBuilder, requestor),
-		ViewAPI:  NewViewAPI(urlBuilder, requestor),
+	cfg := clientConfig{requestor: BasicAuthRequestor(username, apiKey)}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	capabilities := &capabilitiesAPI{URLBuilder: urlBuilder}
+	requestor := cfg.requestor.WithMiddleware(capabilities.RecordVersion)
+	capabilities.requestor, capabilities.plugins = requestor, NewPluginAPI(urlBuilder, requestor)
+
+	jobAPI := NewJobAPI(urlBuilder, requestor)
+	jobAPI.capabilities = capabilities
+	var jobs JobAPI = jobAPI
+	var queue QueueAPI = NewQueueAPI(urlBuilder, requestor)
+	if cfg.events {
+		subscriber := NewEventSubscriber(urlBuilder, requestor)
+		subscriber.capabilities = capabilities
+		jobs = eventJobAPI{JobAPI: jobs, subscriber: subscriber}
+		queue = eventQueueAPI{QueueAPI: queue, subscriber: subscriber}
+	}
+	multibranchAPI := NewMultibranchAPI(urlBuilder, requestor)
+	multibranchAPI.capabilities = capabilities
+	credentialsAPI := NewCredentialsAPI(urlBuilder, requestor)
+	credentialsAPI.capabilities = capabilities
+	return client{
+		JobAPI:    jobs,
+		QueueAPI:  queue,
+		ViewAPI:   NewViewAPI(urlBuilder, requestor),
+		PluginAPI: NewPluginAPI(urlBuilder, requestor),
+		SystemAPI: NewSystemAPI(urlBuilder, requestor),
+		UserAPI:   NewUserAPI(urlBuilder, requestor),
+
+		MultibranchAPI:  multibranchAPI,
+		CredentialsAPI:  credentialsAPI,
+		PipelineAPI:     NewPipelineAPI(urlBuilder, requestor),
+		CapabilitiesAPI: capabilities,
 	}
 }
 
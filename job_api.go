@@ -2,8 +2,10 @@ package gojenkins
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -24,6 +26,21 @@ type BuildInfo struct {
 
 type BuildNumber uint32
 
+// BuildPermalink names a build of a job by its result, e.g. LastSuccessfulBuild.
+type BuildPermalink string
+
+const (
+	LastBuild             BuildPermalink = "lastBuild"
+	LastCompletedBuild    BuildPermalink = "lastCompletedBuild"
+	LastSuccessfulBuild   BuildPermalink = "lastSuccessfulBuild"
+	LastStableBuild       BuildPermalink = "lastStableBuild"
+	LastFailedBuild       BuildPermalink = "lastFailedBuild"
+	LastUnsuccessfulBuild BuildPermalink = "lastUnsuccessfulBuild"
+)
+
+// ErrBuildNotFound is returned, wrapped with the permalink and job, by LastBuild when the job has no such build.
+var ErrBuildNotFound = errors.New("build not found")
+
 const (
 	// DefaultWaitForBuildToBeCompletedTimeout is the default time that the client would poll job status before giving up.
 	DefaultWaitForBuildToBeCompletedTimeout = time.Duration(25 * time.Minute)
@@ -31,6 +48,7 @@ const (
 
 // JobAPI is the interface to interact with a Jenkins job.
 type JobAPI interface {
+	// ScheduleBuild schedules a build of the job. Jobs in folders are named by their full name, e.g. folder/job.
 	ScheduleBuild(ctx context.Context, jobName string, params url.Values) (QueueID, error)
 	GetBuilds(ctx context.Context, jobName string, m, n uint32) ([]BuildInfo, error)
 	BuildInfo(ctx context.Context, item QueueItem) (BuildInfo, error)
@@ -39,16 +57,34 @@ type JobAPI interface {
 	// A timeout is enforced via context.
 	// If the context does not have a timeout DefaultWaitForBuildToBeCompletedTimeout is used.
 	// To not bombard jenkins after every unsuccessful call we wait for retryAfter before retrying.
-	WaitUntilBuildIsComplete(ctx context.Context, item QueueItem, retryAfter time.Duration) (BuildInfo, error)
+	// WithBuildProgress reports the progress of the build after every poll.
+	WaitUntilBuildIsComplete(ctx context.Context, item QueueItem, retryAfter time.Duration, opts ...WaitOption) (BuildInfo, error)
+
+	// StopBuild aborts a running build.
+	StopBuild(ctx context.Context, item QueueItem) error
+
+	// StreamConsoleOutput copies the console output of the build to w as it is produced until the build completes.
+	// Jenkins is polled for new output every retryAfter.
+	StreamConsoleOutput(ctx context.Context, item QueueItem, w io.Writer, retryAfter time.Duration) error
+
+	// ListJobs returns the jobs of the whole instance, sorted by full name, walking folders at most
+	// DefaultListJobsConcurrency at once. See ListJobsOption for how to limit the walk and filter jobs.
+	ListJobs(ctx context.Context, opts ...ListJobsOption) ([]Job, error)
+
+	// LastBuild returns the build a permalink of the job points to, e.g. the last successful build.
+	// It returns an error matching ErrBuildNotFound if the job has no such build.
+	LastBuild(ctx context.Context, jobName string, permalink BuildPermalink) (BuildInfo, error)
 }
 
 func NewJobAPI(u URLBuilder, r Requestor) jobAPI {
-	return jobAPI{u, r}
+	return jobAPI{URLBuilder: u, requestor: r}
 }
 
 type jobAPI struct {
 	URLBuilder
 	requestor Requestor
+	// capabilities, if set, avoids requests to endpoints of missing plugins.
+	capabilities *capabilitiesAPI
 }
 
 var queueIDRegex = regexp.MustCompile(`.*/item/(\d+)`)
@@ -56,7 +92,8 @@ var queueIDRegex = regexp.MustCompile(`.*/item/(\d+)`)
 func (j jobAPI) ScheduleBuild(ctx context.Context, jobName string, params url.Values) (QueueID, error) {
 	resp := j.requestor.Do(ctx, Request{
 		Method:      http.MethodPost,
-		URL:         j.URLBuilder.JSONEndpoint("job", jobName, "buildWithParameters"),
+		URL:         j.URLBuilder.JSONEndpoint(jobPath(jobName, "buildWithParameters")...),
+		Route:       "job/{name}/buildWithParameters/api/json",
 		ContentType: ContentTypeFormURLEncoded,
 		Body:        strings.NewReader(params.Encode()),
 	})
@@ -73,7 +110,7 @@ func (j jobAPI) ScheduleBuild(ctx context.Context, jobName string, params url.Va
 
 	err := resp.VerifyAndDecode(NoOpDecoder, HTTPStatusCodeVerifier(http.StatusCreated), queueIDFromLocation)
 	if err != nil {
-		return 0, nil
+		return 0, err
 	}
 
 	return QueueID(queueID), nil
@@ -82,7 +119,8 @@ func (j jobAPI) ScheduleBuild(ctx context.Context, jobName string, params url.Va
 func (j jobAPI) GetBuilds(ctx context.Context, jobName string, m, n uint32) ([]BuildInfo, error) {
 	resp := j.requestor.Do(ctx, Request{
 		Method: http.MethodGet,
-		URL:    j.URLBuilder.JSONEndpoint("job", jobName),
+		URL:    j.URLBuilder.JSONEndpoint(jobPath(jobName)...),
+		Route:  "job/{name}/api/json",
 		Query:  url.Values{"tree": []string{fmt.Sprintf("builds[%v]{%v,%v}", buildInfoTree, m, n)}},
 	})
 
@@ -97,14 +135,18 @@ func (j jobAPI) GetBuilds(ctx context.Context, jobName string, m, n uint32) ([]B
 	return buildInfoResponse.Builds, nil
 }
 
-func (j jobAPI) WaitUntilBuildIsComplete(ctx context.Context, item QueueItem, retryAfter time.Duration) (BuildInfo, error) {
+func (j jobAPI) WaitUntilBuildIsComplete(ctx context.Context, item QueueItem, retryAfter time.Duration, opts ...WaitOption) (BuildInfo, error) {
+	cfg := newWaitConfig(opts)
 	ctx, cancelFn := setTimeoutIfNotSet(ctx, DefaultWaitForBuildToBeCompletedTimeout)
 	defer cancelFn()
 
 	var buildInfo BuildInfo
+	var iteration int
 	err := retryUntilFalseOrError(ctx, retryAfter, func() (bool, error) {
+		iteration++
+		pollIteration(ctx, j.requestor, "WaitUntilBuildIsComplete", iteration)
 		var err error
-		buildInfo, err = j.BuildInfo(ctx, item)
+		buildInfo, err = j.pollBuild(ctx, item, cfg)
 		return buildInfo.Building, err
 	})
 
@@ -116,7 +158,101 @@ func (j jobAPI) BuildInfo(ctx context.Context, item QueueItem) (BuildInfo, error
 	query := url.Values{"tree": []string{fmt.Sprintf("%v,building", buildInfoTree)}}
 	var buildInfo BuildInfo
 	err := j.requestor.
-		Do(ctx, Request{Method: http.MethodGet, URL: buildURL, Query: query}).
+		Do(ctx, Request{Method: http.MethodGet, URL: buildURL, Route: "job/{name}/{number}/api/json", Query: query}).
 		VerifyAndDecode(JsonDecoder(&buildInfo))
 	return buildInfo, err
 }
+
+func (j jobAPI) LastBuild(ctx context.Context, jobName string, permalink BuildPermalink) (BuildInfo, error) {
+	switch permalink {
+	case LastBuild, LastCompletedBuild, LastSuccessfulBuild, LastStableBuild, LastFailedBuild, LastUnsuccessfulBuild:
+	default:
+		return BuildInfo{}, fmt.Errorf("unknown build permalink %q", permalink)
+	}
+
+	// The permalink is requested through the job, as it is null when the job has no such build,
+	// while the permalink itself would be 404 just like a missing job.
+	resp := j.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    j.URLBuilder.JSONEndpoint(jobPath(jobName)...),
+		Route:  "job/{name}/api/json",
+		Query:  url.Values{"tree": []string{fmt.Sprintf("%v[%v,building]", permalink, buildInfoTree)}},
+	})
+
+	var permalinks map[BuildPermalink]json.RawMessage
+	if err := resp.VerifyAndDecode(JsonDecoder(&permalinks)); err != nil {
+		return BuildInfo{}, err
+	}
+	// Jenkins answers null, or leaves out a permalink it does not know, if the job has no such build.
+	raw, ok := permalinks[permalink]
+	if !ok {
+		return BuildInfo{}, fmt.Errorf("%v of %v: %w", permalink, jobName, ErrBuildNotFound)
+	}
+	var buildInfo *BuildInfo
+	if err := json.Unmarshal(raw, &buildInfo); err != nil {
+		return BuildInfo{}, fmt.Errorf("failed to decode %v of %v: %w", permalink, jobName, err)
+	}
+	if buildInfo == nil {
+		return BuildInfo{}, fmt.Errorf("%v of %v: %w", permalink, jobName, ErrBuildNotFound)
+	}
+	return *buildInfo, nil
+}
+
+func (j jobAPI) StopBuild(ctx context.Context, item QueueItem) error {
+	resp := j.requestor.Do(ctx, Request{
+		Method: http.MethodPost,
+		URL:    fmt.Sprintf("%v/stop", strings.TrimSuffix(item.URL, "/")),
+		Route:  "job/{name}/{number}/stop",
+	})
+	return resp.VerifyAndDecode(NoOpDecoder, statusCodeVerifier(http.StatusOK, http.StatusNoContent))
+}
+
+func (j jobAPI) StreamConsoleOutput(ctx context.Context, item QueueItem, w io.Writer, retryAfter time.Duration) error {
+	logURL := fmt.Sprintf("%v/logText/progressiveText", strings.TrimSuffix(item.URL, "/"))
+
+	var start int64
+	var iteration int
+	return retryUntilFalseOrError(ctx, retryAfter, func() (bool, error) {
+		iteration++
+		pollIteration(ctx, j.requestor, "StreamConsoleOutput", iteration)
+
+		var textSize, copied int64
+		var moreData, verified bool
+		progressiveTextHeaders := func(resp *http.Response) error {
+			if err := StatusOKVerifier(resp); err != nil {
+				return err
+			}
+			size, err := strconv.ParseInt(resp.Header.Get("X-Text-Size"), 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse X-Text-Size: %v", err)
+			}
+			textSize, moreData, verified = size, resp.Header.Get("X-More-Data") == "true", true
+			return nil
+		}
+		// The body of an error response must not end up in the console output.
+		copyTo := func(r io.Reader) error {
+			if !verified {
+				return NoOpDecoder(r)
+			}
+			var err error
+			copied, err = io.Copy(w, r)
+			return err
+		}
+
+		err := j.requestor.
+			Do(ctx, Request{
+				Method: http.MethodGet,
+				URL:    logURL,
+				Route:  "job/{name}/{number}/logText/progressiveText",
+				Query:  url.Values{"start": []string{strconv.FormatInt(start, 10)}},
+			}).
+			VerifyAndDecode(copyTo, progressiveTextHeaders)
+		if err != nil {
+			// Output copied before the connection dropped must not be written again when retrying.
+			start += copied
+			return false, err
+		}
+		start = textSize
+		return moreData, nil
+	})
+}
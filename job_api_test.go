@@ -2,10 +2,13 @@ package gojenkins
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -28,6 +31,36 @@ func TestJobApi_ScheduleBuild(t *testing.T) {
 	}
 }
 
+func TestJobApi_ScheduleBuildOfJobInFolder(t *testing.T) {
+	var actualPath string
+	api, cleanupFn := jobAPITestClient("/job/service/job/feature%2Flogin/buildWithParameters/api/json",
+		func(resp http.ResponseWriter, req *http.Request) {
+			actualPath = req.URL.EscapedPath()
+			resp.Header().Add("Location", "http://testurl.com/queue/item/3")
+			resp.WriteHeader(http.StatusCreated)
+		})
+	defer cleanupFn()
+
+	if _, err := api.ScheduleBuild(context.TODO(), "service/feature%2Flogin", url.Values{}); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if expectedPath := "/job/service/job/feature%252Flogin/buildWithParameters/api/json"; actualPath != expectedPath {
+		t.Errorf("Expected %v but got %v", expectedPath, actualPath)
+	}
+}
+
+func TestJobApi_ScheduleBuildReturnsErrors(t *testing.T) {
+	api, cleanupFn := jobAPITestClient("/job/Test/buildWithParameters/api/json",
+		func(resp http.ResponseWriter, req *http.Request) {
+			resp.WriteHeader(http.StatusNotFound)
+		})
+	defer cleanupFn()
+
+	if _, err := api.ScheduleBuild(context.TODO(), "Test", url.Values{}); err == nil {
+		t.Fatal("Expected an error but got none")
+	}
+}
+
 func TestJobApi_GetBuilds(t *testing.T) {
 	var actualRequest *http.Request
 	api, cleanupFn := jobAPITestClient("/job/Test/api/json", func(resp http.ResponseWriter, req *http.Request) {
@@ -99,6 +132,93 @@ func TestJobApi_WaitUntilBuildIsComplete_TimesoutCorrectly(t *testing.T) {
 	}
 }
 
+func TestJobApi_StopBuild(t *testing.T) {
+	var actualMethod string
+	api, cleanupFn := jobAPITestClient("/job/Test/2/stop", func(resp http.ResponseWriter, req *http.Request) {
+		actualMethod = req.Method
+	})
+	defer cleanupFn()
+
+	err := api.StopBuild(context.TODO(), QueueItem{Number: 2, URL: fmt.Sprintf("%v/job/Test/2/", api.URLBuilder)})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if actualMethod != http.MethodPost {
+		t.Errorf("Expected POST but got %v", actualMethod)
+	}
+}
+
+func TestJobApi_StreamConsoleOutput(t *testing.T) {
+	chunks := []string{"Started\n", "Building\n", "Finished: SUCCESS\n"}
+	var calls int
+	api, cleanupFn := jobAPITestClient("/job/Test/2/logText/progressiveText", func(resp http.ResponseWriter, req *http.Request) {
+		start, _ := strconv.Atoi(req.URL.Query().Get("start"))
+		log := strings.Join(chunks[:calls+1], "")
+		resp.Header().Set("X-Text-Size", strconv.Itoa(len(log)))
+		if calls < len(chunks)-1 {
+			resp.Header().Set("X-More-Data", "true")
+		}
+		fmt.Fprint(resp, log[start:])
+		calls++
+	})
+	defer cleanupFn()
+
+	var output strings.Builder
+	err := api.StreamConsoleOutput(context.TODO(), QueueItem{Number: 2, URL: fmt.Sprintf("%v/job/Test/2/", api.URLBuilder)}, &output, time.Millisecond)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if expected := strings.Join(chunks, ""); output.String() != expected {
+		t.Errorf("Expected %q but got %q", expected, output.String())
+	}
+}
+
+func TestJobApi_StreamConsoleOutput_ResumesAfterDroppedConnection(t *testing.T) {
+	log := "Started\nBuilding\nFinished: SUCCESS\n"
+	var starts []string
+	api, cleanupFn := jobAPITestClient("/job/Test/2/logText/progressiveText", func(resp http.ResponseWriter, req *http.Request) {
+		starts = append(starts, req.URL.Query().Get("start"))
+		start, _ := strconv.Atoi(req.URL.Query().Get("start"))
+		resp.Header().Set("X-Text-Size", strconv.Itoa(len(log)))
+		if len(starts) == 1 {
+			// The connection drops after "Started\n" although the whole log was announced.
+			resp.Header().Set("Content-Length", strconv.Itoa(len(log)))
+			fmt.Fprint(resp, log[:len("Started\n")])
+			return
+		}
+		fmt.Fprint(resp, log[start:])
+	})
+	defer cleanupFn()
+
+	var output strings.Builder
+	err := api.StreamConsoleOutput(context.TODO(), QueueItem{Number: 2, URL: fmt.Sprintf("%v/job/Test/2/", api.URLBuilder)}, &output, time.Millisecond)
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if output.String() != log {
+		t.Errorf("Expected %q but got %q", log, output.String())
+	}
+	if expected := []string{"0", strconv.Itoa(len("Started\n"))}; strings.Join(starts, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected to resume at %v but got %v", expected, starts)
+	}
+}
+
+func TestJobApi_StreamConsoleOutput_DoesNotWriteErrorResponses(t *testing.T) {
+	api, cleanupFn := jobAPITestClient("/job/Test/2/logText/progressiveText", func(resp http.ResponseWriter, req *http.Request) {
+		http.Error(resp, "not found", http.StatusNotFound)
+	})
+	defer cleanupFn()
+
+	var output strings.Builder
+	err := api.StreamConsoleOutput(context.TODO(), QueueItem{Number: 2, URL: fmt.Sprintf("%v/job/Test/2", api.URLBuilder)}, &output, time.Millisecond)
+
+	if err == nil || output.Len() != 0 {
+		t.Errorf("Expected an error and no output but got %v and %q", err, output.String())
+	}
+}
+
 func launchAndWaitUntilBuildIsComplete(fn http.HandlerFunc, timeout time.Duration, retryAfter time.Duration) (BuildInfo, error) {
 	client, cleanupFn := jobAPITestClient("/job/Test/1/api/json", fn)
 	defer cleanupFn()
@@ -159,3 +279,74 @@ const buildCompleteResponse = `
   "url" : "http://testurl.com/jenkins/job/Test/2"
 }
 `
+
+func TestJobApi_LastBuild(t *testing.T) {
+	tests := map[string]struct {
+		response          string
+		expectedBuildInfo BuildInfo
+		expectedErr       error
+	}{
+		"should return the build of the permalink": {
+			response:          `{"_class":"hudson.model.FreeStyleProject","lastSuccessfulBuild":{"_class":"hudson.model.FreeStyleBuild","building":false,"number":41,"queueId":7,"result":"SUCCESS","url":"http://localhost:8080/job/deploy/41/"}}`,
+			expectedBuildInfo: BuildInfo{Number: 41, QueueID: 7, URL: "http://localhost:8080/job/deploy/41/", Result: "SUCCESS"},
+		},
+		"should fail if the job has no such build": {
+			response:    `{"_class":"hudson.model.FreeStyleProject","lastSuccessfulBuild":null}`,
+			expectedErr: ErrBuildNotFound,
+		},
+		"should fail if the permalink is left out": {
+			response:    `{"_class":"hudson.model.FreeStyleProject"}`,
+			expectedErr: ErrBuildNotFound,
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var actualRequest *http.Request
+			api, cleanupFn := jobAPITestClient("/job/deploy/api/json", func(resp http.ResponseWriter, req *http.Request) {
+				actualRequest = req
+				fmt.Fprint(resp, testdata.response)
+			})
+			defer cleanupFn()
+
+			buildInfo, err := api.LastBuild(context.TODO(), "deploy", LastSuccessfulBuild)
+			if !errors.Is(err, testdata.expectedErr) {
+				t.Fatalf("Expected error %v but got %v", testdata.expectedErr, err)
+			}
+			if buildInfo != testdata.expectedBuildInfo {
+				t.Errorf("Expected %+v but got %+v", testdata.expectedBuildInfo, buildInfo)
+			}
+			expectedTree := fmt.Sprintf("lastSuccessfulBuild[%v,building]", buildInfoTree)
+			if tree := actualRequest.URL.Query().Get("tree"); tree != expectedTree {
+				t.Errorf("Expected tree %v but got %v", expectedTree, tree)
+			}
+		})
+	}
+}
+
+func TestJobApi_LastBuildOfMissingJobFails(t *testing.T) {
+	api, cleanupFn := jobAPITestClient("/job/other/api/json", stringResponseHandleFunc("{}"))
+	defer cleanupFn()
+
+	_, err := api.LastBuild(context.TODO(), "deploy", LastBuild)
+	if !hasStatusCode(err, http.StatusNotFound) || errors.Is(err, ErrBuildNotFound) {
+		t.Errorf("Expected a 404 error but got %v", err)
+	}
+}
+
+func TestJobApi_LastBuildReturnsDecodeErrors(t *testing.T) {
+	api, cleanupFn := jobAPITestClient("/job/deploy/api/json", stringResponseHandleFunc(`{"lastBuild":"41"}`))
+	defer cleanupFn()
+
+	_, err := api.LastBuild(context.TODO(), "deploy", LastBuild)
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || errors.Is(err, ErrBuildNotFound) {
+		t.Errorf("Expected a decode error but got %v", err)
+	}
+}
+
+func TestJobApi_LastBuildWithUnknownPermalinkFails(t *testing.T) {
+	if _, err := NewJobAPI("http://localhost", BasicAuthRequestor("", "")).LastBuild(context.TODO(), "deploy", "lastGreenBuild"); err == nil {
+		t.Errorf("Expected an error for an unknown permalink")
+	}
+}
@@ -0,0 +1,214 @@
+package gojenkins
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// DefaultListJobsConcurrency is the number of folders ListJobs lists at once unless WithFolderConcurrency is given.
+const DefaultListJobsConcurrency = 4
+
+// Job is a job or folder returned by ListJobs.
+type Job struct {
+	Name string
+	// FullName includes the folders of the job, e.g. folder/job. It can be passed to the other methods of JobAPI.
+	FullName string
+	// Class is the java class of the job, e.g. org.jenkinsci.plugins.workflow.job.WorkflowJob.
+	Class     string
+	URL       string
+	Color     string
+	Buildable bool
+	// Label is the label expression the job is restricted to run on, empty if it can run anywhere.
+	Label string
+	// Folder reports whether the job contains other jobs, e.g. a folder or a multibranch project.
+	Folder bool
+}
+
+// ListJobsOption configures JobAPI.ListJobs.
+type ListJobsOption func(*listJobsConfig)
+
+type listJobsConfig struct {
+	maxDepth    int
+	concurrency int
+	name        *regexp.Regexp
+	classes     map[string]bool
+	label       *string
+}
+
+// WithMaxFolderDepth limits how deep ListJobs walks into folders. Jobs at the root have depth 1.
+func WithMaxFolderDepth(depth int) ListJobsOption {
+	return func(c *listJobsConfig) {
+		c.maxDepth = depth
+	}
+}
+
+// WithFolderConcurrency limits how many folders ListJobs lists at once.
+func WithFolderConcurrency(n int) ListJobsOption {
+	return func(c *listJobsConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithJobNameMatching configures ListJobs to only return jobs whose full name matches re.
+// Folders are walked whether they match or not.
+func WithJobNameMatching(re *regexp.Regexp) ListJobsOption {
+	return func(c *listJobsConfig) {
+		c.name = re
+	}
+}
+
+// WithJobClasses configures ListJobs to only return jobs of one of the java classes.
+func WithJobClasses(classes ...string) ListJobsOption {
+	return func(c *listJobsConfig) {
+		if c.classes == nil {
+			c.classes = make(map[string]bool)
+		}
+		for _, class := range classes {
+			c.classes[class] = true
+		}
+	}
+}
+
+// WithJobLabel configures ListJobs to only return jobs restricted to the label expression.
+// Pass an empty label to only return jobs which can run anywhere.
+func WithJobLabel(label string) ListJobsOption {
+	return func(c *listJobsConfig) {
+		c.label = &label
+	}
+}
+
+func (c listJobsConfig) matches(job Job) bool {
+	return (c.name == nil || c.name.MatchString(job.FullName)) &&
+		(c.classes == nil || c.classes[job.Class]) &&
+		(c.label == nil || *c.label == job.Label)
+}
+
+func (c listJobsConfig) walks(depth int) bool {
+	return c.maxDepth <= 0 || depth < c.maxDepth
+}
+
+const listJobsTree = "jobs[name,fullName,url,color,buildable,assignedLabel[name],jobs[name]]"
+
+func (j jobAPI) ListJobs(ctx context.Context, opts ...ListJobsOption) ([]Job, error) {
+	cfg := listJobsConfig{concurrency: DefaultListJobsConcurrency}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.concurrency <= 0 {
+		cfg.concurrency = 1
+	}
+	if j.capabilities.lacks(ctx, hasFolders) {
+		// Without the folders plugin there is nothing to walk into below the root.
+		cfg.maxDepth = 1
+	}
+
+	ctx, cancelFn := context.WithCancel(ctx)
+	defer cancelFn()
+
+	var (
+		mu       sync.Mutex
+		jobs     []Job
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	folders := make(chan struct{}, cfg.concurrency)
+
+	var walk func(folder string, depth int)
+	walk = func(folder string, depth int) {
+		defer wg.Done()
+
+		var children []Job
+		var err error
+		select {
+		case folders <- struct{}{}:
+			children, err = j.listFolder(ctx, folder)
+			<-folders
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+				cancelFn()
+			}
+			return
+		}
+		for _, job := range children {
+			if cfg.matches(job) {
+				jobs = append(jobs, job)
+			}
+			if job.Folder && cfg.walks(depth) {
+				wg.Add(1)
+				go walk(job.FullName, depth+1)
+			}
+		}
+	}
+
+	wg.Add(1)
+	walk("", 1)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].FullName < jobs[b].FullName })
+	return jobs, nil
+}
+
+// listFolder returns the jobs of the folder, or of the root if folder is empty.
+func (j jobAPI) listFolder(ctx context.Context, folder string) ([]Job, error) {
+	var folderResponse struct {
+		Jobs []struct {
+			Class         string `json:"_class"`
+			Name          string
+			FullName      string
+			URL           string
+			Color         string
+			Buildable     bool
+			AssignedLabel *struct {
+				Name string
+			}
+			Jobs []struct{}
+		}
+	}
+
+	folderURL, route := j.URLBuilder.JSONEndpoint(), "api/json"
+	if folder != "" {
+		folderURL, route = j.URLBuilder.JSONEndpoint(jobPath(folder)...), "job/{name}/api/json"
+	}
+	resp := j.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    folderURL,
+		Route:  route,
+		Query:  url.Values{"tree": []string{listJobsTree}},
+	})
+
+	if err := resp.VerifyAndDecode(JsonDecoder(&folderResponse)); err != nil {
+		return nil, err
+	}
+
+	jobs := make([]Job, 0, len(folderResponse.Jobs))
+	for _, item := range folderResponse.Jobs {
+		job := Job{
+			Name:      item.Name,
+			FullName:  item.FullName,
+			Class:     item.Class,
+			URL:       item.URL,
+			Color:     item.Color,
+			Buildable: item.Buildable,
+			Folder:    item.Jobs != nil,
+		}
+		if item.AssignedLabel != nil {
+			job.Label = item.AssignedLabel.Name
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,182 @@
+package gojenkins
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestJobApi_ListJobs(t *testing.T) {
+	tests := map[string]struct {
+		opts             []ListJobsOption
+		expectedJobNames []string
+	}{
+		"should walk all folders": {
+			expectedJobNames: []string{"deploy", "team", "team/api", "team/service", "team/service/PR-1", "team/service/main"},
+		},
+		"should limit the depth": {
+			opts:             []ListJobsOption{WithMaxFolderDepth(2)},
+			expectedJobNames: []string{"deploy", "team", "team/api", "team/service"},
+		},
+		"should filter by name": {
+			opts:             []ListJobsOption{WithJobNameMatching(regexp.MustCompile(`^team/service/`))},
+			expectedJobNames: []string{"team/service/PR-1", "team/service/main"},
+		},
+		"should filter by class": {
+			opts:             []ListJobsOption{WithJobClasses("hudson.model.FreeStyleProject")},
+			expectedJobNames: []string{"deploy", "team/api"},
+		},
+		"should filter by label": {
+			opts:             []ListJobsOption{WithJobLabel("linux && docker"), WithFolderConcurrency(1)},
+			expectedJobNames: []string{"team/api"},
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			api, cleanupFn := listJobsTestClient(map[string]http.HandlerFunc{
+				"/api/json":                      stringResponseHandleFunc(rootJobsResponse),
+				"/job/team/api/json":             stringResponseHandleFunc(teamFolderResponse),
+				"/job/team/job/service/api/json": stringResponseHandleFunc(serviceProjectResponse),
+			})
+			defer cleanupFn()
+
+			jobs, err := api.ListJobs(context.TODO(), testdata.opts...)
+			if err != nil {
+				t.Fatalf("Expected jobs but got error %v", err)
+			}
+			var names []string
+			for _, job := range jobs {
+				names = append(names, job.FullName)
+			}
+			if !reflect.DeepEqual(testdata.expectedJobNames, names) {
+				t.Errorf("Expected jobs %v but got %v", testdata.expectedJobNames, names)
+			}
+		})
+	}
+}
+
+func TestJobApi_ListJobsReturnsJobDetails(t *testing.T) {
+	api, cleanupFn := listJobsTestClient(map[string]http.HandlerFunc{
+		"/api/json":          stringResponseHandleFunc(rootJobsResponse),
+		"/job/team/api/json": stringResponseHandleFunc(`{"jobs":[]}`),
+	})
+	defer cleanupFn()
+
+	jobs, err := api.ListJobs(context.TODO())
+	if err != nil {
+		t.Fatalf("Expected jobs but got error %v", err)
+	}
+	expectedJobs := []Job{
+		{
+			Name:      "deploy",
+			FullName:  "deploy",
+			Class:     "hudson.model.FreeStyleProject",
+			URL:       "http://localhost:8080/job/deploy/",
+			Color:     "blue",
+			Buildable: true,
+		},
+		{
+			Name:     "team",
+			FullName: "team",
+			Class:    "com.cloudbees.hudson.plugins.folder.Folder",
+			URL:      "http://localhost:8080/job/team/",
+			Folder:   true,
+		},
+	}
+	if !reflect.DeepEqual(expectedJobs, jobs) {
+		t.Errorf("Expected jobs %+v but got %+v", expectedJobs, jobs)
+	}
+}
+
+func TestJobApi_ListJobsFailsIfAFolderCannotBeListed(t *testing.T) {
+	api, cleanupFn := listJobsTestClient(map[string]http.HandlerFunc{
+		"/api/json": stringResponseHandleFunc(rootJobsResponse),
+		"/job/team/api/json": func(resp http.ResponseWriter, req *http.Request) {
+			resp.WriteHeader(http.StatusForbidden)
+		},
+	})
+	defer cleanupFn()
+
+	if _, err := api.ListJobs(context.TODO()); !hasStatusCode(err, http.StatusForbidden) {
+		t.Errorf("Expected a 403 error but got %v", err)
+	}
+}
+
+func listJobsTestClient(handlers map[string]http.HandlerFunc) (jobAPI, func()) {
+	mux := http.NewServeMux()
+	for path, fn := range handlers {
+		mux.HandleFunc(path, fn)
+	}
+
+	srvr := httptest.NewServer(mux)
+	api := NewJobAPI(URLBuilder(srvr.URL), BasicAuthRequestor("", "").WithRetryPolicy(NoRetryPolicy))
+	return api, srvr.Close
+}
+
+const rootJobsResponse = `
+{
+  "_class": "hudson.model.Hudson",
+  "jobs": [{
+    "_class": "hudson.model.FreeStyleProject",
+    "name": "deploy",
+    "fullName": "deploy",
+    "url": "http://localhost:8080/job/deploy/",
+    "color": "blue",
+    "buildable": true,
+    "assignedLabel": null
+  }, {
+    "_class": "com.cloudbees.hudson.plugins.folder.Folder",
+    "name": "team",
+    "fullName": "team",
+    "url": "http://localhost:8080/job/team/",
+    "jobs": [{"_class": "hudson.model.FreeStyleProject", "name": "api"}]
+  }]
+}
+`
+
+const teamFolderResponse = `
+{
+  "_class": "com.cloudbees.hudson.plugins.folder.Folder",
+  "jobs": [{
+    "_class": "hudson.model.FreeStyleProject",
+    "name": "api",
+    "fullName": "team/api",
+    "url": "http://localhost:8080/job/team/job/api/",
+    "color": "red",
+    "buildable": true,
+    "assignedLabel": {"_class": "hudson.model.labels.LabelExpression$And", "name": "linux && docker"}
+  }, {
+    "_class": "org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject",
+    "name": "service",
+    "fullName": "team/service",
+    "url": "http://localhost:8080/job/team/job/service/",
+    "color": "blue",
+    "jobs": []
+  }]
+}
+`
+
+const serviceProjectResponse = `
+{
+  "_class": "org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject",
+  "jobs": [{
+    "_class": "org.jenkinsci.plugins.workflow.job.WorkflowJob",
+    "name": "main",
+    "fullName": "team/service/main",
+    "url": "http://localhost:8080/job/team/job/service/job/main/",
+    "color": "blue",
+    "buildable": true
+  }, {
+    "_class": "org.jenkinsci.plugins.workflow.job.WorkflowJob",
+    "name": "PR-1",
+    "fullName": "team/service/PR-1",
+    "url": "http://localhost:8080/job/team/job/service/job/PR-1/",
+    "color": "red",
+    "buildable": true
+  }]
+}
+`
@@ -0,0 +1,79 @@
+package gojenkins
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Logger is used by the client to trace requests and report errors.
+// A *slog.Logger from the log/slog package satisfies this interface.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Error(string, ...interface{}) {}
+
+const redacted = "REDACTED"
+
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"Jenkins-Crumb":       true,
+}
+
+// redactHeaders returns a copy of h with credentials and crumbs replaced so that it is safe to log.
+func redactHeaders(h http.Header) http.Header {
+	redactedHeader := make(http.Header, len(h))
+	for name, values := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] || strings.Contains(strings.ToLower(name), "crumb") {
+			values = []string{redacted}
+		}
+		redactedHeader[name] = values
+	}
+	return redactedHeader
+}
+
+// redactURL removes any user info from rawURL so that it is safe to log.
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.Redacted()
+}
+
+// Logging returns a Middleware that traces every request passing through it to l at debug level.
+// Failed responses are reported at error level when they are decoded.
+func Logging(l Logger) Middleware {
+	return func(next Requestor) Requestor {
+		return RequestorFunc(func(ctx context.Context, req Request) *Response {
+			info := requestInfo(req, attemptFromContext(ctx))
+
+			start := time.Now()
+			resp := next.Do(ctx, req)
+			latency := time.Since(start)
+
+			if resp.response == nil {
+				l.Debug("jenkins request failed",
+					"method", info.Method, "url", info.URL, "attempt", info.Attempt, "latency", latency,
+					"requestHeaders", redactHeaders(req.Header), "error", resp.err)
+			} else {
+				l.Debug("jenkins request",
+					"method", info.Method, "url", info.URL, "status", resp.response.StatusCode, "attempt", info.Attempt,
+					"latency", latency, "requestHeaders", redactHeaders(req.Header), "responseHeaders", redactHeaders(resp.response.Header))
+			}
+			if resp.logger == nil {
+				resp.logger = l
+			}
+			return resp
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package gojenkins
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestor_WithLogger_TracesRequestsWithRedactedCredentials(t *testing.T) {
+	srvr := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.Header().Set("Set-Cookie", "JSESSIONID=secret-session")
+		resp.WriteHeader(http.StatusNotFound)
+	}))
+	defer srvr.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	requestor := BasicAuthRequestor("user", "secret-api-key").WithLogger(logger)
+
+	err := requestor.
+		Do(context.TODO(), Request{Method: http.MethodGet, URL: srvr.URL + "/job/Test/api/json"}).
+		VerifyAndDecode(NoOpDecoder)
+	if err == nil {
+		t.Fatal("Expected an error but got none")
+	}
+
+	logs := buf.String()
+	for _, expected := range []string{"method=GET", "/job/Test/api/json", "status=404", "attempt=1", "latency=", "level=ERROR", redacted} {
+		if !strings.Contains(logs, expected) {
+			t.Errorf("Expected logs to contain %q but got %v", expected, logs)
+		}
+	}
+	for _, secret := range []string{"c2VjcmV0LWFwaS1rZXk", "secret-api-key", "secret-session"} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("Expected logs to not contain %q but got %v", secret, logs)
+		}
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	header := http.Header{
+		"Authorization": []string{"Basic dXNlcjpwYXNz"},
+		"Jenkins-Crumb": []string{"crumb"},
+		".crumb":        []string{"crumb"},
+		"Content-Type":  []string{ContentTypeJSON},
+	}
+
+	redactedHeader := redactHeaders(header)
+
+	for _, name := range []string{"Authorization", "Jenkins-Crumb", ".crumb"} {
+		if value := redactedHeader[name][0]; value != redacted {
+			t.Errorf("Expected header %v to be redacted but was %v", name, value)
+		}
+	}
+	if value := redactedHeader.Get("Content-Type"); value != ContentTypeJSON {
+		t.Errorf("Expected Content-Type to be %v but was %v", ContentTypeJSON, value)
+	}
+	if header.Get("Authorization") == redacted {
+		t.Error("Expected original header to not be modified")
+	}
+}
@@ -0,0 +1,279 @@
+package gojenkins
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultWaitForScanToBeCompletedTimeout is the default time that the client would poll a scan before giving up.
+	DefaultWaitForScanToBeCompletedTimeout = time.Duration(10 * time.Minute)
+)
+
+// BranchKind is the kind of SCM head a job of a multibranch project builds.
+type BranchKind string
+
+const (
+	BranchKindBranch      BranchKind = "branch"
+	BranchKindPullRequest BranchKind = "pull-request"
+	BranchKindTag         BranchKind = "tag"
+)
+
+// Branch is a job of a multibranch project, building a branch, pull request or tag.
+type Branch struct {
+	// Name is the name of the job, which jenkins encodes, e.g. feature%2Flogin for the branch feature/login.
+	Name string
+	// FullName is the name to schedule builds of the job with, e.g. project/feature%2Flogin.
+	FullName string
+	// DisplayName is the name of the branch or the title of the pull request.
+	DisplayName string
+	URL         string
+	Kind        BranchKind
+	// Primary reports whether the branch is the primary branch of the repository, e.g. main.
+	Primary bool
+	// SCMURL links to the branch or pull request in the SCM, empty if the SCM does not provide it.
+	SCMURL    string
+	Color     string
+	Buildable bool
+}
+
+// MultibranchScan is a scan of a multibranch project for branches, called branch indexing, or of
+// an organization folder for repositories.
+type MultibranchScan struct {
+	Building bool
+	// Result of the scan once it completed, e.g. SUCCESS or FAILURE.
+	Result    string
+	Timestamp time.Time
+	Duration  time.Duration
+}
+
+// MultibranchAPI is the interface to interact with multibranch projects and organization folders.
+// Projects in folders are named by their full name, e.g. folder/project.
+// The methods of a Client return an error matching ErrUnsupported if the branch-api plugin is missing.
+type MultibranchAPI interface {
+	// ListBranches returns the jobs of a multibranch project. The jobs of an organization folder are
+	// the multibranch projects of its repositories.
+	ListBranches(ctx context.Context, project string) ([]Branch, error)
+
+	// ScanMultibranch schedules a scan of the project for new and deleted branches.
+	ScanMultibranch(ctx context.Context, project string) error
+
+	// MultibranchScanLog returns the log of the last scan of the project.
+	MultibranchScanLog(ctx context.Context, project string) (string, error)
+
+	// WaitUntilScanIsComplete polls jenkins until the scan of the project, scheduled or running, completes.
+	// A timeout is enforced via context.
+	// If the context does not have a timeout DefaultWaitForScanToBeCompletedTimeout is used.
+	// To not bombard jenkins after every unsuccessful call we wait for retryAfter before retrying.
+	WaitUntilScanIsComplete(ctx context.Context, project string, retryAfter time.Duration) (MultibranchScan, error)
+}
+
+func NewMultibranchAPI(u URLBuilder, r Requestor) multibranchAPI {
+	return multibranchAPI{URLBuilder: u, requestor: r}
+}
+
+type multibranchAPI struct {
+	URLBuilder
+	requestor Requestor
+	// capabilities, if set, tells a missing project from a missing branch-api plugin.
+	capabilities *capabilitiesAPI
+}
+
+// unsupported wraps a 404 with ErrUnsupported if the branch-api plugin is missing.
+func (m multibranchAPI) unsupported(ctx context.Context, err error) error {
+	return m.capabilities.missingAsUnsupported(ctx, err, "multibranch projects", hasMultibranch)
+}
+
+// branchViews map the views jenkins groups the jobs of a multibranch project in to their kind.
+// Jobs in no view are branches.
+var branchViews = map[string]BranchKind{
+	"change-requests": BranchKindPullRequest,
+	"tags":            BranchKindTag,
+}
+
+const (
+	objectMetadataAction          = "jenkins.scm.api.metadata.ObjectMetadataAction"
+	primaryInstanceMetadataAction = "jenkins.scm.api.metadata.PrimaryInstanceMetadataAction"
+)
+
+func (m multibranchAPI) ListBranches(ctx context.Context, project string) ([]Branch, error) {
+	var projectResponse struct {
+		Jobs []struct {
+			Name        string
+			DisplayName string
+			URL         string
+			Color       string
+			Buildable   bool
+			Actions     []struct {
+				Class             string `json:"_class"`
+				ObjectDisplayName string
+				ObjectURL         string `json:"objectUrl"`
+			}
+		}
+		Views []struct {
+			Name string
+			Jobs []struct {
+				Name string
+			}
+		}
+	}
+
+	resp := m.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    m.URLBuilder.JSONEndpoint(jobPath(project)...),
+		Route:  "job/{name}/api/json",
+		Query: url.Values{"tree": []string{
+			"jobs[name,displayName,url,color,buildable,actions[objectDisplayName,objectUrl]],views[name,jobs[name]]",
+		}},
+	})
+
+	if err := resp.VerifyAndDecode(JsonDecoder(&projectResponse)); err != nil {
+		return nil, m.unsupported(ctx, err)
+	}
+
+	kinds := make(map[string]BranchKind)
+	for _, view := range projectResponse.Views {
+		if kind, ok := branchViews[view.Name]; ok {
+			for _, job := range view.Jobs {
+				kinds[job.Name] = kind
+			}
+		}
+	}
+
+	var branches []Branch
+	for _, job := range projectResponse.Jobs {
+		branch := Branch{
+			Name:        job.Name,
+			FullName:    strings.Trim(project, "/") + "/" + job.Name,
+			DisplayName: job.DisplayName,
+			URL:         job.URL,
+			Kind:        BranchKindBranch,
+			Color:       job.Color,
+			Buildable:   job.Buildable,
+		}
+		if kind, ok := kinds[job.Name]; ok {
+			branch.Kind = kind
+		}
+		for _, action := range job.Actions {
+			switch action.Class {
+			case objectMetadataAction:
+				if action.ObjectDisplayName != "" {
+					branch.DisplayName = action.ObjectDisplayName
+				}
+				branch.SCMURL = action.ObjectURL
+			case primaryInstanceMetadataAction:
+				branch.Primary = true
+			}
+		}
+		branches = append(branches, branch)
+	}
+	return branches, nil
+}
+
+func (m multibranchAPI) ScanMultibranch(ctx context.Context, project string) error {
+	resp := m.requestor.Do(ctx, Request{
+		Method: http.MethodPost,
+		URL:    m.URLBuilder.Endpoint(jobPath(project, "build")...),
+		Route:  "job/{name}/build",
+		Query:  url.Values{"delay": []string{"0"}},
+	})
+	return m.unsupported(ctx, resp.VerifyAndDecode(NoOpDecoder, statusCodeVerifier(http.StatusOK, http.StatusCreated, http.StatusFound)))
+}
+
+func (m multibranchAPI) MultibranchScanLog(ctx context.Context, project string) (string, error) {
+	var log string
+	err := m.computation(ctx, project, "consoleText", textDecoder(&log))
+	return log, err
+}
+
+func (m multibranchAPI) WaitUntilScanIsComplete(ctx context.Context, project string, retryAfter time.Duration) (MultibranchScan, error) {
+	ctx, cancelFn := setTimeoutIfNotSet(ctx, DefaultWaitForScanToBeCompletedTimeout)
+	defer cancelFn()
+
+	var scan MultibranchScan
+	var iteration int
+	err := retryUntilFalseOrError(ctx, retryAfter, func() (bool, error) {
+		iteration++
+		pollIteration(ctx, m.requestor, "WaitUntilScanIsComplete", iteration)
+
+		queued, err := m.isQueued(ctx, project)
+		if err != nil || queued {
+			return true, err
+		}
+		scan, err = m.lastScan(ctx, project)
+		return scan.Building, err
+	})
+
+	return scan, err
+}
+
+// isQueued reports whether a scan of the project is waiting in the queue.
+func (m multibranchAPI) isQueued(ctx context.Context, project string) (bool, error) {
+	var queueResponse struct {
+		Items []struct {
+			Task struct {
+				URL string
+			}
+		}
+	}
+
+	resp := m.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    m.URLBuilder.JSONEndpoint("queue"),
+		Route:  "queue/api/json",
+		Query:  url.Values{"tree": []string{"items[task[url]]"}},
+	})
+
+	if err := resp.VerifyAndDecode(JsonDecoder(&queueResponse)); err != nil {
+		return false, err
+	}
+
+	projectPath := "/" + strings.Join(jobPath(project), "/") + "/"
+	for _, item := range queueResponse.Items {
+		if strings.HasSuffix(item.Task.URL, projectPath) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m multibranchAPI) lastScan(ctx context.Context, project string) (MultibranchScan, error) {
+	var computation struct {
+		Building  bool
+		Result    string
+		Timestamp int64
+		Duration  int64
+	}
+
+	if err := m.computation(ctx, project, jsonEndpoint, JsonDecoder(&computation)); err != nil {
+		return MultibranchScan{}, err
+	}
+	return MultibranchScan{
+		Building:  computation.Building,
+		Result:    computation.Result,
+		Timestamp: time.Unix(0, computation.Timestamp*int64(time.Millisecond)),
+		Duration:  time.Duration(computation.Duration) * time.Millisecond,
+	}, nil
+}
+
+// computation requests the endpoint of the last scan of the project. Jenkins serves the scan of a
+// multibranch project at indexing and the scan of an organization folder at computation.
+func (m multibranchAPI) computation(ctx context.Context, project, endpoint string, decoder Decoder) error {
+	var err error
+	for _, computation := range []string{"indexing", "computation"} {
+		err = m.requestor.
+			Do(ctx, Request{
+				Method: http.MethodGet,
+				URL:    m.URLBuilder.Endpoint(jobPath(project, computation, endpoint)...),
+				Route:  "job/{name}/" + computation + "/" + endpoint,
+			}).
+			VerifyAndDecode(decoder)
+		if !hasStatusCode(err, http.StatusNotFound) {
+			return err
+		}
+	}
+	return m.unsupported(ctx, err)
+}
@@ -0,0 +1,201 @@
+package gojenkins
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMultibranchAPI_ListBranches(t *testing.T) {
+	api, cleanupFn := multibranchAPITestClient(map[string]http.HandlerFunc{
+		"/job/team/job/service/api/json": stringResponseHandleFunc(multibranchProjectResponse),
+	})
+	defer cleanupFn()
+
+	branches, err := api.ListBranches(context.TODO(), "team/service")
+	if err != nil {
+		t.Fatalf("Expected branches but got error %v", err)
+	}
+	expectedBranches := []Branch{
+		{
+			Name:        "main",
+			FullName:    "team/service/main",
+			DisplayName: "main",
+			URL:         "http://localhost:8080/job/team/job/service/job/main/",
+			Kind:        BranchKindBranch,
+			Primary:     true,
+			SCMURL:      "https://github.com/acme/service/tree/main",
+			Color:       "blue",
+			Buildable:   true,
+		},
+		{
+			Name:        "feature%2Flogin",
+			FullName:    "team/service/feature%2Flogin",
+			DisplayName: "feature/login",
+			URL:         "http://localhost:8080/job/team/job/service/job/feature%252Flogin/",
+			Kind:        BranchKindBranch,
+			Color:       "notbuilt",
+			Buildable:   true,
+		},
+		{
+			Name:        "PR-42",
+			FullName:    "team/service/PR-42",
+			DisplayName: "Add login page",
+			URL:         "http://localhost:8080/job/team/job/service/job/PR-42/",
+			Kind:        BranchKindPullRequest,
+			SCMURL:      "https://github.com/acme/service/pull/42",
+			Color:       "red",
+			Buildable:   true,
+		},
+	}
+	if !reflect.DeepEqual(expectedBranches, branches) {
+		t.Errorf("Expected branches %+v but got %+v", expectedBranches, branches)
+	}
+}
+
+func TestMultibranchAPI_ScanMultibranch(t *testing.T) {
+	var actualMethod, actualDelay string
+	api, cleanupFn := multibranchAPITestClient(map[string]http.HandlerFunc{
+		"/job/service/build": func(resp http.ResponseWriter, req *http.Request) {
+			actualMethod = req.Method
+			actualDelay = req.URL.Query().Get("delay")
+		},
+	})
+	defer cleanupFn()
+
+	if err := api.ScanMultibranch(context.TODO(), "service"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if actualMethod != http.MethodPost || actualDelay != "0" {
+		t.Errorf("Expected a POST without delay but got %v delay=%v", actualMethod, actualDelay)
+	}
+}
+
+func TestMultibranchAPI_MultibranchScanLog(t *testing.T) {
+	tests := map[string]struct {
+		expectedPath string
+	}{
+		"multibranch project": {
+			expectedPath: "/job/service/indexing/consoleText",
+		},
+		"organization folder": {
+			expectedPath: "/job/service/computation/consoleText",
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			api, cleanupFn := multibranchAPITestClient(map[string]http.HandlerFunc{
+				testdata.expectedPath: stringResponseHandleFunc("Checking branches...\nFinished: SUCCESS\n"),
+			})
+			defer cleanupFn()
+
+			log, err := api.MultibranchScanLog(context.TODO(), "service")
+			if err != nil {
+				t.Fatalf("Expected the log but got error %v", err)
+			}
+			if log != "Checking branches...\nFinished: SUCCESS\n" {
+				t.Errorf("Expected the scan log but got %q", log)
+			}
+		})
+	}
+}
+
+func TestMultibranchAPI_WaitUntilScanIsComplete(t *testing.T) {
+	api, cleanupFn := multibranchAPITestClient(map[string]http.HandlerFunc{
+		"/queue/api/json": responseCountCheckingHandlerFunc(t,
+			`{"items":[{"task":{"url":"http://localhost:8080/job/other/"}},{"task":{"url":"http://localhost:8080/job/service/"}}]}`,
+			`{"items":[]}`,
+			`{"items":[]}`,
+		),
+		"/job/service/indexing/api/json": responseCountCheckingHandlerFunc(t,
+			`{"building":true,"result":null,"timestamp":1700000000000,"duration":0}`,
+			`{"building":false,"result":"SUCCESS","timestamp":1700000000000,"duration":1500}`,
+		),
+	})
+	defer cleanupFn()
+
+	scan, err := api.WaitUntilScanIsComplete(context.TODO(), "service", time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	expectedScan := MultibranchScan{Result: "SUCCESS", Timestamp: time.Unix(1700000000, 0), Duration: 1500 * time.Millisecond}
+	if expectedScan != scan {
+		t.Errorf("Expected scan %+v but got %+v", expectedScan, scan)
+	}
+}
+
+func TestMultibranchAPI_WaitUntilScanIsCompleteOfUnknownProjectFails(t *testing.T) {
+	api, cleanupFn := multibranchAPITestClient(map[string]http.HandlerFunc{
+		"/queue/api/json": stringResponseHandleFunc(`{"items":[]}`),
+	})
+	defer cleanupFn()
+
+	if _, err := api.WaitUntilScanIsComplete(context.TODO(), "missing", time.Millisecond); !hasStatusCode(err, http.StatusNotFound) {
+		t.Errorf("Expected a 404 error but got %v", err)
+	}
+}
+
+func multibranchAPITestClient(handlers map[string]http.HandlerFunc) (multibranchAPI, func()) {
+	mux := http.NewServeMux()
+	for path, fn := range handlers {
+		mux.HandleFunc(path, fn)
+	}
+
+	srvr := httptest.NewServer(mux)
+	api := NewMultibranchAPI(URLBuilder(srvr.URL), BasicAuthRequestor("", ""))
+	return api, srvr.Close
+}
+
+const multibranchProjectResponse = `
+{
+  "_class": "org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject",
+  "jobs": [{
+    "_class": "org.jenkinsci.plugins.workflow.job.WorkflowJob",
+    "actions": [{}, {
+      "_class": "jenkins.scm.api.metadata.ObjectMetadataAction",
+      "objectDisplayName": null,
+      "objectUrl": "https://github.com/acme/service/tree/main"
+    }, {
+      "_class": "jenkins.scm.api.metadata.PrimaryInstanceMetadataAction"
+    }],
+    "buildable": true,
+    "color": "blue",
+    "displayName": "main",
+    "name": "main",
+    "url": "http://localhost:8080/job/team/job/service/job/main/"
+  }, {
+    "_class": "org.jenkinsci.plugins.workflow.job.WorkflowJob",
+    "actions": [{}],
+    "buildable": true,
+    "color": "notbuilt",
+    "displayName": "feature/login",
+    "name": "feature%2Flogin",
+    "url": "http://localhost:8080/job/team/job/service/job/feature%252Flogin/"
+  }, {
+    "_class": "org.jenkinsci.plugins.workflow.job.WorkflowJob",
+    "actions": [{}, {
+      "_class": "jenkins.scm.api.metadata.ObjectMetadataAction",
+      "objectDisplayName": "Add login page",
+      "objectUrl": "https://github.com/acme/service/pull/42"
+    }],
+    "buildable": true,
+    "color": "red",
+    "displayName": "PR-42",
+    "name": "PR-42",
+    "url": "http://localhost:8080/job/team/job/service/job/PR-42/"
+  }],
+  "views": [{
+    "_class": "jenkins.branch.MultiBranchProjectViewHolder$ViewImpl",
+    "name": "default",
+    "jobs": [{"name": "main"}, {"name": "feature%2Flogin"}]
+  }, {
+    "_class": "jenkins.branch.MultiBranchProjectViewHolder$ViewImpl",
+    "name": "change-requests",
+    "jobs": [{"name": "PR-42"}]
+  }]
+}
+`
@@ -0,0 +1,115 @@
+package gojenkins
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// Observer is notified about every request made by a Requestor and every poll made while waiting
+// for a build. It is the extension point for tracing and metrics adapters, e.g. OpenTelemetry or Prometheus.
+type Observer interface {
+	// RequestStarted is called before every attempt of a request.
+	// The returned context is used to send the request, which allows a tracer to propagate its span.
+	// The returned function is called once the attempt completes.
+	RequestStarted(ctx context.Context, info RequestInfo) (context.Context, func(ResponseInfo))
+
+	// RequestRetried is called when an attempt failed with a transient error and will be retried after wait.
+	RequestRetried(ctx context.Context, info RequestInfo, wait time.Duration)
+
+	// PollIteration is called every time WaitUntilBuildIsQueued or WaitUntilBuildIsComplete polls jenkins.
+	// iteration starts at 1.
+	PollIteration(ctx context.Context, operation string, iteration int)
+}
+
+// RequestInfo describes a single attempt of a request.
+type RequestInfo struct {
+	Method string
+	// URL is the request url with credentials removed.
+	URL string
+	// Route is a low cardinality template of the url path, e.g. job/{name}/api/json.
+	Route   string
+	Attempt int
+}
+
+// ResponseInfo describes the outcome of a single attempt of a request.
+type ResponseInfo struct {
+	// StatusCode is 0 if no response was received.
+	StatusCode int
+	Latency    time.Duration
+	Err        error
+}
+
+// MultiObserver returns an Observer that notifies all of the given observers in order.
+func MultiObserver(observers ...Observer) Observer {
+	return multiObserver(observers)
+}
+
+type multiObserver []Observer
+
+func (m multiObserver) RequestStarted(ctx context.Context, info RequestInfo) (context.Context, func(ResponseInfo)) {
+	doneFns := make([]func(ResponseInfo), 0, len(m))
+	for _, o := range m {
+		var done func(ResponseInfo)
+		ctx, done = o.RequestStarted(ctx, info)
+		doneFns = append(doneFns, done)
+	}
+	return ctx, func(resp ResponseInfo) {
+		for i := len(doneFns) - 1; i >= 0; i-- {
+			doneFns[i](resp)
+		}
+	}
+}
+
+func (m multiObserver) RequestRetried(ctx context.Context, info RequestInfo, wait time.Duration) {
+	for _, o := range m {
+		o.RequestRetried(ctx, info, wait)
+	}
+}
+
+func (m multiObserver) PollIteration(ctx context.Context, operation string, iteration int) {
+	for _, o := range m {
+		o.PollIteration(ctx, operation, iteration)
+	}
+}
+
+type nopObserver struct{}
+
+func (nopObserver) RequestStarted(ctx context.Context, _ RequestInfo) (context.Context, func(ResponseInfo)) {
+	return ctx, func(ResponseInfo) {}
+}
+
+func (nopObserver) RequestRetried(context.Context, RequestInfo, time.Duration) {}
+
+func (nopObserver) PollIteration(context.Context, string, int) {}
+
+// Observe returns a Middleware that notifies o about every request passing through it.
+func Observe(o Observer) Middleware {
+	return func(next Requestor) Requestor {
+		return RequestorFunc(func(ctx context.Context, req Request) *Response {
+			info := requestInfo(req, attemptFromContext(ctx))
+			ctx, done := o.RequestStarted(ctx, info)
+
+			start := time.Now()
+			resp := next.Do(ctx, req)
+			respInfo := ResponseInfo{Latency: time.Since(start), Err: resp.err}
+			if resp.response != nil {
+				respInfo.StatusCode = resp.response.StatusCode
+			}
+			done(respInfo)
+
+			return resp
+		})
+	}
+}
+
+func requestInfo(req Request, attempt int) RequestInfo {
+	info := RequestInfo{Method: req.Method, URL: req.URL, Route: req.Route, Attempt: attempt}
+	if u, err := url.Parse(req.URL); err == nil {
+		info.URL = redactURL(u)
+		if info.Route == "" {
+			info.Route = u.Path
+		}
+	}
+	return info
+}
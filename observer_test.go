@@ -0,0 +1,82 @@
+package gojenkins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRequestor_WithObserver_ObservesRequestsRetriesAndPolls(t *testing.T) {
+	responses := []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusOK}
+	bodies := []string{"", buildInProgressResponse, buildCompleteResponse}
+	var calls int
+	srvr := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(responses[calls])
+		fmt.Fprint(resp, bodies[calls])
+		calls++
+	}))
+	defer srvr.Close()
+
+	observer := new(recordingObserver)
+	api := NewJobAPI(URLBuilder(srvr.URL), BasicAuthRequestor("", "").WithRetryPolicy(fastRetryPolicy).WithObserver(observer))
+
+	item := QueueItem{URL: fmt.Sprintf("%v/job/Test/2", srvr.URL)}
+	if _, err := api.WaitUntilBuildIsComplete(context.TODO(), item, time.Millisecond); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	expectedRequests := []string{
+		"GET job/{name}/{number}/api/json attempt=1 status=503",
+		"GET job/{name}/{number}/api/json attempt=2 status=200",
+		"GET job/{name}/{number}/api/json attempt=1 status=200",
+	}
+	if !reflect.DeepEqual(expectedRequests, observer.requests) {
+		t.Errorf("Expected requests %v but got %v", expectedRequests, observer.requests)
+	}
+	if observer.retries != 1 {
+		t.Errorf("Expected 1 retry but got %v", observer.retries)
+	}
+	expectedPolls := []string{"WaitUntilBuildIsComplete 1", "WaitUntilBuildIsComplete 2"}
+	if !reflect.DeepEqual(expectedPolls, observer.polls) {
+		t.Errorf("Expected polls %v but got %v", expectedPolls, observer.polls)
+	}
+}
+
+func TestMultiObserver_NotifiesAllObservers(t *testing.T) {
+	first, second := new(recordingObserver), new(recordingObserver)
+	observer := MultiObserver(first, second)
+
+	_, done := observer.RequestStarted(context.TODO(), RequestInfo{Method: http.MethodGet, Route: "queue/api/json", Attempt: 1})
+	done(ResponseInfo{StatusCode: http.StatusOK})
+	observer.PollIteration(context.TODO(), "WaitUntilBuildIsQueued", 1)
+
+	for _, o := range []*recordingObserver{first, second} {
+		if len(o.requests) != 1 || len(o.polls) != 1 {
+			t.Errorf("Expected observer to be notified of 1 request and 1 poll but got %v and %v", o.requests, o.polls)
+		}
+	}
+}
+
+type recordingObserver struct {
+	requests []string
+	retries  int
+	polls    []string
+}
+
+func (o *recordingObserver) RequestStarted(ctx context.Context, info RequestInfo) (context.Context, func(ResponseInfo)) {
+	return ctx, func(resp ResponseInfo) {
+		o.requests = append(o.requests, fmt.Sprintf("%v %v attempt=%v status=%v", info.Method, info.Route, info.Attempt, resp.StatusCode))
+	}
+}
+
+func (o *recordingObserver) RequestRetried(context.Context, RequestInfo, time.Duration) {
+	o.retries++
+}
+
+func (o *recordingObserver) PollIteration(_ context.Context, operation string, iteration int) {
+	o.polls = append(o.polls, fmt.Sprintf("%v %v", operation, iteration))
+}
@@ -0,0 +1,172 @@
+package gojenkins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PipelineError is an error in a declarative pipeline. Line and Column are 0 if jenkins did not report a position.
+type PipelineError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e PipelineError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("line %v, column %v: %v", e.Line, e.Column, e.Message)
+}
+
+// InvalidPipelineError is returned by ToJSON and ToJenkinsfile when jenkins cannot convert the pipeline.
+type InvalidPipelineError struct {
+	Errors []PipelineError
+}
+
+func (e *InvalidPipelineError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return "invalid pipeline: " + strings.Join(messages, "; ")
+}
+
+// PipelineAPI is the interface to the declarative pipeline converter of the Pipeline: Declarative plugin.
+// Its methods return an error matching ErrUnsupported if the plugin is not installed.
+type PipelineAPI interface {
+	// Validate lints the Jenkinsfile of a declarative pipeline and returns its errors, none if it is valid.
+	Validate(ctx context.Context, jenkinsfile string) ([]PipelineError, error)
+
+	// ToJSON converts the Jenkinsfile of a declarative pipeline to its JSON representation.
+	ToJSON(ctx context.Context, jenkinsfile string) (json.RawMessage, error)
+
+	// ToJenkinsfile converts the JSON representation of a declarative pipeline to a Jenkinsfile.
+	ToJenkinsfile(ctx context.Context, pipeline json.RawMessage) (string, error)
+}
+
+func NewPipelineAPI(u URLBuilder, r Requestor) pipelineAPI {
+	return pipelineAPI{u, r}
+}
+
+type pipelineAPI struct {
+	URLBuilder
+	requestor Requestor
+}
+
+const pipelineConverter = "pipeline-model-converter"
+
+// pipelineErrorRegex matches the errors of the validate endpoint, e.g.
+// WorkflowScript: 1: Missing required section "agent" @ line 1, column 1.
+var pipelineErrorRegex = regexp.MustCompile(`^WorkflowScript: \d+: (.*) @ line (\d+), column (\d+)\.$`)
+
+func (p pipelineAPI) Validate(ctx context.Context, jenkinsfile string) ([]PipelineError, error) {
+	var output string
+	err := p.convert(ctx, "validate", url.Values{"jenkinsfile": []string{jenkinsfile}}, textDecoder(&output))
+	if err != nil {
+		return nil, err
+	}
+
+	output = strings.TrimSpace(output)
+	if strings.HasPrefix(output, "Jenkinsfile successfully validated.") {
+		return nil, nil
+	}
+	var errs []PipelineError
+	for _, line := range strings.Split(output, "\n") {
+		if submatch := pipelineErrorRegex.FindStringSubmatch(strings.TrimSpace(line)); submatch != nil {
+			lineNumber, _ := strconv.Atoi(submatch[2])
+			column, _ := strconv.Atoi(submatch[3])
+			errs = append(errs, PipelineError{Line: lineNumber, Column: column, Message: submatch[1]})
+		}
+	}
+	if len(errs) == 0 {
+		errs = append(errs, PipelineError{Message: output})
+	}
+	return errs, nil
+}
+
+func (p pipelineAPI) ToJSON(ctx context.Context, jenkinsfile string) (json.RawMessage, error) {
+	var result struct {
+		JSON json.RawMessage
+	}
+	err := p.convertJSON(ctx, "toJson", url.Values{"jenkinsfile": []string{jenkinsfile}}, &result)
+	return result.JSON, err
+}
+
+func (p pipelineAPI) ToJenkinsfile(ctx context.Context, pipeline json.RawMessage) (string, error) {
+	var result struct {
+		Jenkinsfile string
+	}
+	err := p.convertJSON(ctx, "toJenkinsfile", url.Values{"json": []string{string(pipeline)}}, &result)
+	return result.Jenkinsfile, err
+}
+
+// convertJSON posts to a converter endpoint which answers with JSON and decodes its data into v on success.
+func (p pipelineAPI) convertJSON(ctx context.Context, endpoint string, params url.Values, v interface{}) error {
+	var response struct {
+		Data json.RawMessage
+	}
+	if err := p.convert(ctx, endpoint, params, JsonDecoder(&response)); err != nil {
+		return err
+	}
+
+	var result struct {
+		Result string
+		Errors []pipelineErrorJSON
+	}
+	if err := json.Unmarshal(response.Data, &result); err != nil {
+		return err
+	}
+	if result.Result != "success" {
+		invalid := &InvalidPipelineError{}
+		for _, err := range result.Errors {
+			invalid.Errors = append(invalid.Errors, err.pipelineError())
+		}
+		return invalid
+	}
+	return json.Unmarshal(response.Data, v)
+}
+
+func (p pipelineAPI) convert(ctx context.Context, endpoint string, params url.Values, decoder Decoder) error {
+	resp := p.requestor.Do(ctx, Request{
+		Method:      http.MethodPost,
+		URL:         p.URLBuilder.Endpoint(pipelineConverter, endpoint),
+		Route:       pipelineConverter + "/" + endpoint,
+		ContentType: ContentTypeFormURLEncoded,
+		Body:        strings.NewReader(params.Encode()),
+	})
+	return notFoundAsUnsupported(resp.VerifyAndDecode(decoder), "declarative pipeline converter")
+}
+
+// pipelineErrorJSON is an error reported by the JSON converter endpoints. Jenkins reports a position
+// for errors in a Jenkinsfile and one or more messages for errors in the JSON representation.
+type pipelineErrorJSON struct {
+	Line    int
+	Column  int
+	Message string
+	Error   json.RawMessage
+}
+
+func (e pipelineErrorJSON) pipelineError() PipelineError {
+	err := PipelineError{Line: e.Line, Column: e.Column, Message: e.Message}
+	if err.Message != "" {
+		return err
+	}
+	var message string
+	var messages []string
+	switch {
+	case json.Unmarshal(e.Error, &message) == nil:
+		err.Message = message
+	case json.Unmarshal(e.Error, &messages) == nil:
+		err.Message = strings.Join(messages, "; ")
+	default:
+		err.Message = string(e.Error)
+	}
+	return err
+}
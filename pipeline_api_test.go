@@ -0,0 +1,159 @@
+package gojenkins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPipelineAPI_Validate(t *testing.T) {
+	tests := map[string]struct {
+		response       string
+		expectedErrors []PipelineError
+	}{
+		"valid pipeline": {
+			response: "Jenkinsfile successfully validated.\n",
+		},
+		"invalid pipeline": {
+			response: validateErrorsResponse,
+			expectedErrors: []PipelineError{
+				{Line: 4, Column: 9, Message: `Unknown stage section "step". Starting with version 0.5, steps in a stage must be in a ‘steps’ block.`},
+				{Line: 1, Column: 1, Message: `Missing required section "agent"`},
+			},
+		},
+		"error without position": {
+			response:       "Jenkinsfile content 'node {}' did not contain the 'pipeline' step\n",
+			expectedErrors: []PipelineError{{Message: "Jenkinsfile content 'node {}' did not contain the 'pipeline' step"}},
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var actualJenkinsfile string
+			api, cleanupFn := pipelineAPITestClient("/pipeline-model-converter/validate", func(resp http.ResponseWriter, req *http.Request) {
+				actualJenkinsfile = req.FormValue("jenkinsfile")
+				stringResponseHandleFunc(testdata.response)(resp, req)
+			})
+			defer cleanupFn()
+
+			errs, err := api.Validate(context.TODO(), "pipeline {}")
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if actualJenkinsfile != "pipeline {}" {
+				t.Errorf("Expected the jenkinsfile to be posted but got %q", actualJenkinsfile)
+			}
+			if !reflect.DeepEqual(testdata.expectedErrors, errs) {
+				t.Errorf("Expected errors %+v but got %+v", testdata.expectedErrors, errs)
+			}
+		})
+	}
+}
+
+func TestPipelineAPI_ValidateWithoutPluginIsUnsupported(t *testing.T) {
+	api, cleanupFn := pipelineAPITestClient("/other", stringResponseHandleFunc(""))
+	defer cleanupFn()
+
+	if _, err := api.Validate(context.TODO(), "pipeline {}"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected %v but got %v", ErrUnsupported, err)
+	}
+}
+
+func TestPipelineAPI_ToJSON(t *testing.T) {
+	tests := map[string]struct {
+		response     string
+		expectedJSON string
+		expectedErr  error
+	}{
+		"valid pipeline": {
+			response:     `{"status":"ok","data":{"result":"success","json":{"pipeline":{"stages":[],"agent":{"type":"any"}}}}}`,
+			expectedJSON: `{"pipeline":{"stages":[],"agent":{"type":"any"}}}`,
+		},
+		"invalid pipeline": {
+			response: `{"status":"ok","data":{"result":"failure","errors":[{"line":1,"column":1,"message":"Missing required section \"agent\""}]}}`,
+			expectedErr: &InvalidPipelineError{Errors: []PipelineError{
+				{Line: 1, Column: 1, Message: `Missing required section "agent"`},
+			}},
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			api, cleanupFn := pipelineAPITestClient("/pipeline-model-converter/toJson", stringResponseHandleFunc(testdata.response))
+			defer cleanupFn()
+
+			pipeline, err := api.ToJSON(context.TODO(), "pipeline {}")
+			if !reflect.DeepEqual(testdata.expectedErr, err) {
+				t.Fatalf("Expected error %v but got %v", testdata.expectedErr, err)
+			}
+			if string(pipeline) != testdata.expectedJSON {
+				t.Errorf("Expected %v but got %v", testdata.expectedJSON, string(pipeline))
+			}
+		})
+	}
+}
+
+func TestPipelineAPI_ToJenkinsfile(t *testing.T) {
+	tests := map[string]struct {
+		response            string
+		expectedJenkinsfile string
+		expectedErr         error
+	}{
+		"valid pipeline": {
+			response:            `{"status":"ok","data":{"result":"success","jenkinsfile":"pipeline {\n  agent any\n}"}}`,
+			expectedJenkinsfile: "pipeline {\n  agent any\n}",
+		},
+		"invalid pipeline": {
+			response: `{"status":"ok","data":{"result":"failure","errors":[{"jenkinsfile":"null","error":["At /pipeline: object has missing required properties ([\"stages\"])"]}]}}`,
+			expectedErr: &InvalidPipelineError{Errors: []PipelineError{
+				{Message: `At /pipeline: object has missing required properties (["stages"])`},
+			}},
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var actualJSON string
+			api, cleanupFn := pipelineAPITestClient("/pipeline-model-converter/toJenkinsfile", func(resp http.ResponseWriter, req *http.Request) {
+				actualJSON = req.FormValue("json")
+				stringResponseHandleFunc(testdata.response)(resp, req)
+			})
+			defer cleanupFn()
+
+			jenkinsfile, err := api.ToJenkinsfile(context.TODO(), json.RawMessage(`{"pipeline":{"agent":{"type":"any"}}}`))
+			if !reflect.DeepEqual(testdata.expectedErr, err) {
+				t.Fatalf("Expected error %v but got %v", testdata.expectedErr, err)
+			}
+			if jenkinsfile != testdata.expectedJenkinsfile {
+				t.Errorf("Expected %q but got %q", testdata.expectedJenkinsfile, jenkinsfile)
+			}
+			if actualJSON != `{"pipeline":{"agent":{"type":"any"}}}` {
+				t.Errorf("Expected the pipeline to be posted but got %v", actualJSON)
+			}
+		})
+	}
+}
+
+func pipelineAPITestClient(path string, fn http.HandlerFunc) (pipelineAPI, func()) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, fn)
+
+	srvr := httptest.NewServer(mux)
+	api := NewPipelineAPI(URLBuilder(srvr.URL), BasicAuthRequestor("", ""))
+	return api, srvr.Close
+}
+
+const validateErrorsResponse = `Errors encountered validating Jenkinsfile:
+WorkflowScript: 4: Unknown stage section "step". Starting with version 0.5, steps in a stage must be in a ‘steps’ block. @ line 4, column 9.
+           stage('Build') {
+           ^
+
+WorkflowScript: 1: Missing required section "agent" @ line 1, column 1.
+   pipeline {
+   ^
+
+`
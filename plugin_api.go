@@ -0,0 +1,193 @@
+package gojenkins
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+)
+
+// Plugin is a plugin installed on jenkins.
+type Plugin struct {
+	ShortName string
+	LongName  string
+	Version   string
+	Active    bool
+	Enabled   bool
+	// HasUpdate reports whether the update center offers a newer version.
+	HasUpdate    bool
+	Dependencies []PluginDependency
+}
+
+// PluginDependency is a plugin required by another plugin.
+type PluginDependency struct {
+	ShortName string
+	Version   string
+	Optional  bool
+}
+
+// PluginSpec names a plugin to install. The latest version is installed if Version is empty.
+type PluginSpec struct {
+	Name    string
+	Version string
+}
+
+// UpdateCenterJob is an installation or other job run by the update center.
+type UpdateCenterJob struct {
+	ID   int
+	Type string
+	// Name is the short name of the plugin the job installs.
+	Name string
+	// Status is e.g. Pending, Installing, Success or Failure.
+	Status       string
+	ErrorMessage string
+}
+
+// UpdateCenterStatus is the progress of the jobs run by the update center.
+type UpdateCenterStatus struct {
+	Jobs []UpdateCenterJob
+	// RestartRequired reports whether jenkins has to be restarted for installed plugins to be used.
+	RestartRequired bool
+}
+
+// PluginAPI is the interface to manage Jenkins plugins.
+type PluginAPI interface {
+	// ListPlugins returns the installed plugins.
+	ListPlugins(ctx context.Context) ([]Plugin, error)
+
+	// InstallPlugins asks the update center to install the plugins and their dependencies.
+	// Installation happens in the background, use UpdateCenterStatus to follow it.
+	InstallPlugins(ctx context.Context, plugins ...PluginSpec) error
+
+	// EnablePlugin enables a disabled plugin. Jenkins has to be restarted for it to take effect.
+	EnablePlugin(ctx context.Context, shortName string) error
+
+	// DisablePlugin disables a plugin. Jenkins has to be restarted for it to take effect.
+	DisablePlugin(ctx context.Context, shortName string) error
+
+	// UninstallPlugin uninstalls a plugin. Jenkins has to be restarted for it to take effect.
+	UninstallPlugin(ctx context.Context, shortName string) error
+
+	// UpdateCenterStatus returns the progress of plugin installations.
+	// EnablePlugin, DisablePlugin and UninstallPlugin return an error matching ErrUnsupported if the
+	// plugin is not installed.
+	UpdateCenterStatus(ctx context.Context) (UpdateCenterStatus, error)
+}
+
+func NewPluginAPI(u URLBuilder, r Requestor) pluginAPI {
+	return pluginAPI{u, r}
+}
+
+type pluginAPI struct {
+	URLBuilder
+	requestor Requestor
+}
+
+func (p pluginAPI) ListPlugins(ctx context.Context) ([]Plugin, error) {
+	var pluginManager struct {
+		Plugins []Plugin
+	}
+
+	resp := p.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    p.URLBuilder.JSONEndpoint("pluginManager"),
+		Route:  "pluginManager/api/json",
+		Query:  url.Values{"depth": []string{"1"}},
+	})
+
+	if err := resp.VerifyAndDecode(JsonDecoder(&pluginManager)); err != nil {
+		return nil, err
+	}
+	return pluginManager.Plugins, nil
+}
+
+func (p pluginAPI) InstallPlugins(ctx context.Context, plugins ...PluginSpec) error {
+	type install struct {
+		Plugin string `xml:"plugin,attr"`
+	}
+	var body struct {
+		XMLName xml.Name  `xml:"jenkins"`
+		Install []install `xml:"install"`
+	}
+	for _, plugin := range plugins {
+		version := plugin.Version
+		if version == "" {
+			version = "latest"
+		}
+		body.Install = append(body.Install, install{Plugin: plugin.Name + "@" + version})
+	}
+	data, err := xml.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	resp := p.requestor.Do(ctx, Request{
+		Method:      http.MethodPost,
+		URL:         p.URLBuilder.Endpoint("pluginManager", "installNecessaryPlugins"),
+		Route:       "pluginManager/installNecessaryPlugins",
+		ContentType: ContentTypeXML,
+		Body:        bytes.NewReader(data),
+	})
+	return resp.VerifyAndDecode(NoOpDecoder, StatusOKOrFoundVerifier)
+}
+
+func (p pluginAPI) EnablePlugin(ctx context.Context, shortName string) error {
+	return p.pluginAction(ctx, shortName, "makeEnabled")
+}
+
+func (p pluginAPI) DisablePlugin(ctx context.Context, shortName string) error {
+	return p.pluginAction(ctx, shortName, "makeDisabled")
+}
+
+func (p pluginAPI) UninstallPlugin(ctx context.Context, shortName string) error {
+	return p.pluginAction(ctx, shortName, "doUninstall")
+}
+
+func (p pluginAPI) pluginAction(ctx context.Context, shortName, action string) error {
+	resp := p.requestor.Do(ctx, Request{
+		Method: http.MethodPost,
+		URL:    p.URLBuilder.Endpoint("pluginManager", "plugin", shortName, action),
+		Route:  "pluginManager/plugin/{name}/" + action,
+	})
+	// jenkins only serves the actions of installed plugins.
+	return notFoundAsUnsupported(resp.VerifyAndDecode(NoOpDecoder, StatusOKOrFoundVerifier), "plugin "+shortName+" is not installed")
+}
+
+func (p pluginAPI) UpdateCenterStatus(ctx context.Context) (UpdateCenterStatus, error) {
+	var updateCenter struct {
+		Jobs []struct {
+			ID           int
+			Type         string
+			Name         string
+			ErrorMessage string
+			Status       struct {
+				Type string
+			}
+		}
+		RestartRequiredForCompletion bool
+	}
+
+	resp := p.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    p.URLBuilder.JSONEndpoint("updateCenter"),
+		Route:  "updateCenter/api/json",
+		Query:  url.Values{"depth": []string{"1"}},
+	})
+
+	if err := resp.VerifyAndDecode(JsonDecoder(&updateCenter)); err != nil {
+		return UpdateCenterStatus{}, err
+	}
+
+	status := UpdateCenterStatus{RestartRequired: updateCenter.RestartRequiredForCompletion}
+	for _, job := range updateCenter.Jobs {
+		status.Jobs = append(status.Jobs, UpdateCenterJob{
+			ID:           job.ID,
+			Type:         job.Type,
+			Name:         job.Name,
+			Status:       job.Status.Type,
+			ErrorMessage: job.ErrorMessage,
+		})
+	}
+	return status, nil
+}
@@ -0,0 +1,210 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPluginAPI_ListPlugins(t *testing.T) {
+	api, cleanupFn := pluginAPITestClient("/pluginManager/api/json", func(resp http.ResponseWriter, req *http.Request) {
+		if depth := req.URL.Query().Get("depth"); depth != "1" {
+			t.Errorf("Expected depth 1 but got %v", depth)
+		}
+		stringResponseHandleFunc(pluginManagerResponse)(resp, req)
+	})
+	defer cleanupFn()
+
+	plugins, err := api.ListPlugins(context.TODO())
+	if err != nil {
+		t.Fatalf("Expected plugins but got error %v", err)
+	}
+	expectedPlugins := []Plugin{{
+		ShortName:    "git",
+		LongName:     "Git plugin",
+		Version:      "4.11.0",
+		Active:       true,
+		Enabled:      true,
+		HasUpdate:    true,
+		Dependencies: []PluginDependency{{ShortName: "credentials", Version: "2.6.1"}, {ShortName: "ssh-credentials", Version: "1.19", Optional: true}},
+	}}
+	if !reflect.DeepEqual(expectedPlugins, plugins) {
+		t.Errorf("Expected plugins %+v but got %+v", expectedPlugins, plugins)
+	}
+}
+
+func TestPluginAPI_InstallPlugins(t *testing.T) {
+	var actualRequest *http.Request
+	var actualBody string
+	api, cleanupFn := pluginAPITestClient("/pluginManager/installNecessaryPlugins", func(resp http.ResponseWriter, req *http.Request) {
+		// Jenkins redirects to the update center, which is followed with a GET.
+		if req.Method == http.MethodGet {
+			return
+		}
+		actualRequest = req
+		body, _ := ioutil.ReadAll(req.Body)
+		actualBody = string(body)
+		resp.Header().Set("Location", req.URL.Path)
+		resp.WriteHeader(http.StatusFound)
+	})
+	defer cleanupFn()
+
+	err := api.InstallPlugins(context.TODO(), PluginSpec{Name: "git", Version: "4.11.0"}, PluginSpec{Name: "workflow-aggregator"})
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if actualRequest.Method != http.MethodPost || actualRequest.Header.Get("Content-Type") != ContentTypeXML {
+		t.Errorf("Expected a POST of xml but got %v %v", actualRequest.Method, actualRequest.Header.Get("Content-Type"))
+	}
+	expectedBody := `<jenkins><install plugin="git@4.11.0"></install><install plugin="workflow-aggregator@latest"></install></jenkins>`
+	if actualBody != expectedBody {
+		t.Errorf("Expected body %v but got %v", expectedBody, actualBody)
+	}
+}
+
+func TestPluginAPI_PluginActions(t *testing.T) {
+	tests := map[string]struct {
+		action       func(api pluginAPI) error
+		expectedPath string
+	}{
+		"enable": {
+			action:       func(api pluginAPI) error { return api.EnablePlugin(context.TODO(), "git") },
+			expectedPath: "/pluginManager/plugin/git/makeEnabled",
+		},
+		"disable": {
+			action:       func(api pluginAPI) error { return api.DisablePlugin(context.TODO(), "git") },
+			expectedPath: "/pluginManager/plugin/git/makeDisabled",
+		},
+		"uninstall": {
+			action:       func(api pluginAPI) error { return api.UninstallPlugin(context.TODO(), "git") },
+			expectedPath: "/pluginManager/plugin/git/doUninstall",
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var actualMethod string
+			api, cleanupFn := pluginAPITestClient(testdata.expectedPath, func(resp http.ResponseWriter, req *http.Request) {
+				actualMethod = req.Method
+			})
+			defer cleanupFn()
+
+			if err := testdata.action(api); err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if actualMethod != http.MethodPost {
+				t.Errorf("Expected POST but got %v", actualMethod)
+			}
+		})
+	}
+}
+
+func TestPluginAPI_PluginActionOfUnknownPluginFails(t *testing.T) {
+	api, cleanupFn := pluginAPITestClient("/pluginManager/plugin/git/makeEnabled", stringResponseHandleFunc(""))
+	defer cleanupFn()
+
+	if err := api.EnablePlugin(context.TODO(), "missing"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported for an unknown plugin but got %v", err)
+	}
+}
+
+func TestPluginAPI_UpdateCenterStatus(t *testing.T) {
+	api, cleanupFn := pluginAPITestClient("/updateCenter/api/json", stringResponseHandleFunc(updateCenterResponse))
+	defer cleanupFn()
+
+	status, err := api.UpdateCenterStatus(context.TODO())
+	if err != nil {
+		t.Fatalf("Expected status but got error %v", err)
+	}
+	expectedStatus := UpdateCenterStatus{
+		Jobs: []UpdateCenterJob{
+			{ID: 1, Type: "ConnectionCheckJob", Status: ""},
+			{ID: 2, Type: "InstallationJob", Name: "git", Status: "Success"},
+			{ID: 3, Type: "InstallationJob", Name: "broken", Status: "Failure", ErrorMessage: "Failed to download"},
+		},
+		RestartRequired: true,
+	}
+	if !reflect.DeepEqual(expectedStatus, status) {
+		t.Errorf("Expected status %+v but got %+v", expectedStatus, status)
+	}
+}
+
+func pluginAPITestClient(path string, fn http.HandlerFunc) (pluginAPI, func()) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, fn)
+
+	srvr := httptest.NewServer(mux)
+	api := NewPluginAPI(URLBuilder(srvr.URL), BasicAuthRequestor("", ""))
+	return api, srvr.Close
+}
+
+const pluginManagerResponse = `
+{
+  "_class": "hudson.LocalPluginManager",
+  "plugins": [{
+    "active": true,
+    "backupVersion": null,
+    "bundled": false,
+    "deleted": false,
+    "dependencies": [{
+      "optional": false,
+      "shortName": "credentials",
+      "version": "2.6.1"
+    }, {
+      "optional": true,
+      "shortName": "ssh-credentials",
+      "version": "1.19"
+    }],
+    "downgradable": false,
+    "enabled": true,
+    "hasUpdate": true,
+    "longName": "Git plugin",
+    "pinned": false,
+    "shortName": "git",
+    "supportsDynamicLoad": "MAYBE",
+    "url": "https://plugins.jenkins.io/git",
+    "version": "4.11.0"
+  }]
+}
+`
+
+const updateCenterResponse = `
+{
+  "_class": "hudson.model.UpdateCenter",
+  "availables": [],
+  "jobs": [{
+    "_class": "hudson.model.UpdateCenter$ConnectionCheckJob",
+    "errorMessage": null,
+    "id": 1,
+    "type": "ConnectionCheckJob"
+  }, {
+    "_class": "hudson.model.UpdateCenter$InstallationJob",
+    "errorMessage": null,
+    "id": 2,
+    "type": "InstallationJob",
+    "name": "git",
+    "status": {
+      "_class": "hudson.model.UpdateCenter$DownloadJob$Success",
+      "success": true,
+      "type": "Success"
+    }
+  }, {
+    "_class": "hudson.model.UpdateCenter$InstallationJob",
+    "errorMessage": "Failed to download",
+    "id": 3,
+    "type": "InstallationJob",
+    "name": "broken",
+    "status": {
+      "_class": "hudson.model.UpdateCenter$DownloadJob$Failure",
+      "success": false,
+      "type": "Failure"
+    }
+  }],
+  "restartRequiredForCompletion": true,
+  "sites": []
+}
+`
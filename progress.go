@@ -0,0 +1,208 @@
+package gojenkins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WaitOption configures WaitUntilBuildIsQueued and WaitUntilBuildIsComplete.
+type WaitOption func(*waitConfig)
+
+type waitConfig struct {
+	queueProgress func(QueueProgress)
+	buildProgress func(BuildProgress)
+}
+
+func newWaitConfig(opts []WaitOption) waitConfig {
+	var cfg waitConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithQueueProgress calls fn with the state of the queue item every time it is checked while waiting for its build to start.
+func WithQueueProgress(fn func(QueueProgress)) WaitOption {
+	return func(c *waitConfig) {
+		c.queueProgress = fn
+	}
+}
+
+// WithBuildProgress calls fn with the state of the build every time it is checked while waiting for it to complete.
+// Reporting the stage of a pipeline costs an additional request per check.
+func WithBuildProgress(fn func(BuildProgress)) WaitOption {
+	return func(c *waitConfig) {
+		c.buildProgress = fn
+	}
+}
+
+// QueueProgress is the state of a queue item while waiting for its build to start.
+type QueueProgress struct {
+	ID QueueID
+	// Why is the reason the item is still waiting, e.g. "Waiting for next available executor".
+	Why string
+	// Item is the build of the queue item, whose Number is 0 until the build starts.
+	Item QueueItem
+}
+
+// BuildProgress is the state of a build while waiting for it to complete.
+type BuildProgress struct {
+	Info    BuildInfo
+	Elapsed time.Duration
+	// Estimated is the duration jenkins estimates from previous builds, 0 if there is no estimate.
+	Estimated time.Duration
+	// Percent is the progress estimated from Elapsed and Estimated. It is -1 when there is no estimate
+	// and stays at 99 while a build runs longer than estimated.
+	Percent int
+	// Stage is the name of the running stage of a pipeline, empty for other jobs.
+	Stage string
+}
+
+// Remaining returns the estimated time until the build completes, 0 if it is unknown or overdue.
+func (p BuildProgress) Remaining() time.Duration {
+	if !p.Info.Building || p.Estimated <= p.Elapsed {
+		return 0
+	}
+	return p.Estimated - p.Elapsed
+}
+
+const buildProgressTree = buildInfoTree + ",building,timestamp,estimatedDuration"
+
+// buildTiming is a build as returned by jenkins with the fields needed to estimate its progress.
+type buildTiming struct {
+	Number            BuildNumber
+	QueueID           uint32
+	URL               string
+	Result            string
+	Building          bool
+	Timestamp         int64
+	EstimatedDuration int64
+}
+
+func (b buildTiming) progress(now time.Time) BuildProgress {
+	progress := BuildProgress{
+		Info:    BuildInfo{Number: b.Number, QueueID: b.QueueID, URL: b.URL, Result: b.Result, Building: b.Building},
+		Percent: -1,
+	}
+	if b.Timestamp > 0 {
+		progress.Elapsed = now.Sub(time.Unix(0, b.Timestamp*int64(time.Millisecond)))
+	}
+	if b.EstimatedDuration > 0 {
+		progress.Estimated = time.Duration(b.EstimatedDuration) * time.Millisecond
+	}
+
+	switch {
+	case !b.Building:
+		progress.Percent = 100
+	case progress.Estimated > 0:
+		progress.Percent = int(progress.Elapsed * 100 / progress.Estimated)
+		if progress.Percent > 99 {
+			progress.Percent = 99
+		}
+		if progress.Percent < 0 {
+			progress.Percent = 0
+		}
+	}
+	return progress
+}
+
+// buildProgress returns the progress of the build including the running stage if it is a pipeline.
+func (j jobAPI) buildProgress(ctx context.Context, item QueueItem) (BuildProgress, error) {
+	var build buildTiming
+	err := j.requestor.
+		Do(ctx, Request{
+			Method: http.MethodGet,
+			URL:    fmt.Sprintf("%v/%v", strings.TrimSuffix(item.URL, "/"), jsonEndpoint),
+			Route:  "job/{name}/{number}/api/json",
+			Query:  url.Values{"tree": []string{buildProgressTree}},
+		}).
+		VerifyAndDecode(JsonDecoder(&build))
+	if err != nil {
+		return BuildProgress{}, err
+	}
+
+	progress := build.progress(time.Now())
+	if build.Building {
+		progress.Stage = j.runningStage(ctx, item)
+	}
+	return progress, nil
+}
+
+// runningStage returns the name of the running stage of a pipeline using the pipeline stage view api.
+// Failing to get it is not an error as only pipelines have stages.
+func (j jobAPI) runningStage(ctx context.Context, item QueueItem) string {
+	if j.capabilities.lacks(ctx, hasPipelineStageView) {
+		return ""
+	}
+
+	var describe struct {
+		Stages []struct {
+			Name   string
+			Status string
+		}
+	}
+	err := j.requestor.
+		Do(ctx, Request{
+			Method: http.MethodGet,
+			URL:    fmt.Sprintf("%v/wfapi/describe", strings.TrimSuffix(item.URL, "/")),
+			Route:  "job/{name}/{number}/wfapi/describe",
+		}).
+		VerifyAndDecode(JsonDecoder(&describe))
+	if err != nil {
+		return ""
+	}
+	for _, stage := range describe.Stages {
+		if stage.Status == "IN_PROGRESS" || stage.Status == "PAUSED_PENDING_INPUT" {
+			return stage.Name
+		}
+	}
+	return ""
+}
+
+// pollBuild returns the build and reports its progress if it was asked for.
+func (j jobAPI) pollBuild(ctx context.Context, item QueueItem, cfg waitConfig) (BuildInfo, error) {
+	if cfg.buildProgress == nil {
+		return j.BuildInfo(ctx, item)
+	}
+	progress, err := j.buildProgress(ctx, item)
+	if err != nil {
+		return BuildInfo{}, err
+	}
+	cfg.buildProgress(progress)
+	return progress.Info, nil
+}
+
+// queueProgress returns the state of the queue item, or an error matching ErrQueueItemCancelled
+// if it left the queue because it was cancelled.
+func (q queueAPI) queueProgress(ctx context.Context, id QueueID) (QueueProgress, error) {
+	var queueItem struct {
+		Why        string
+		Cancelled  bool
+		Executable struct {
+			Number BuildNumber
+			URL    string
+		}
+	}
+	itemURL := q.URLBuilder.JSONEndpoint("queue", "item", strconv.FormatUint(uint64(id), 10))
+
+	resp := q.requestor.Do(ctx, Request{Method: http.MethodGet, URL: itemURL, Route: "queue/item/{id}/api/json"})
+	err := resp.VerifyAndDecode(JsonDecoder(&queueItem))
+	if err == nil && queueItem.Cancelled {
+		err = fmt.Errorf("queue item %v: %w", id, ErrQueueItemCancelled)
+	}
+	return QueueProgress{ID: id, Why: queueItem.Why, Item: QueueItem(queueItem.Executable)}, err
+}
+
+// pollQueueItem returns the build of the queue item and reports its progress if it was asked for.
+func (q queueAPI) pollQueueItem(ctx context.Context, id QueueID, cfg waitConfig) (QueueItem, error) {
+	progress, err := q.queueProgress(ctx, id)
+	if err == nil && cfg.queueProgress != nil {
+		cfg.queueProgress(progress)
+	}
+	return progress.Item, err
+}
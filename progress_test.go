@@ -0,0 +1,121 @@
+package gojenkins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBuildTiming_Progress(t *testing.T) {
+	now := time.Unix(1000, 0)
+	startedAt := now.Add(-30*time.Second).UnixNano() / int64(time.Millisecond)
+
+	tests := map[string]struct {
+		build             buildTiming
+		expectedPercent   int
+		expectedRemaining time.Duration
+	}{
+		"should estimate progress from the elapsed and estimated duration": {
+			build:             buildTiming{Building: true, Timestamp: startedAt, EstimatedDuration: 120000},
+			expectedPercent:   25,
+			expectedRemaining: 90 * time.Second,
+		},
+		"should stay at 99 percent while running longer than estimated": {
+			build:           buildTiming{Building: true, Timestamp: startedAt, EstimatedDuration: 10000},
+			expectedPercent: 99,
+		},
+		"should be unknown without an estimate": {
+			build:           buildTiming{Building: true, Timestamp: startedAt, EstimatedDuration: -1},
+			expectedPercent: -1,
+		},
+		"should be complete once the build is not building": {
+			build:           buildTiming{Timestamp: startedAt, EstimatedDuration: 120000, Result: "SUCCESS"},
+			expectedPercent: 100,
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			progress := testdata.build.progress(now)
+			if progress.Percent != testdata.expectedPercent {
+				t.Errorf("Expected %v%% but got %v%%", testdata.expectedPercent, progress.Percent)
+			}
+			if remaining := progress.Remaining(); remaining != testdata.expectedRemaining {
+				t.Errorf("Expected %v remaining but got %v", testdata.expectedRemaining, remaining)
+			}
+		})
+	}
+}
+
+func TestJobApi_WaitUntilBuildIsComplete_ReportsProgress(t *testing.T) {
+	startedAt := time.Now().Add(-time.Minute).UnixNano() / int64(time.Millisecond)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/Test/2/api/json", responseCountCheckingHandlerFunc(t,
+		fmt.Sprintf(`{"number":2,"building":true,"timestamp":%v,"estimatedDuration":240000}`, startedAt),
+		fmt.Sprintf(`{"number":2,"building":false,"result":"SUCCESS","timestamp":%v,"estimatedDuration":240000}`, startedAt)))
+	mux.HandleFunc("/job/Test/2/wfapi/describe", stringResponseHandleFunc(
+		`{"stages":[{"name":"Build","status":"SUCCESS"},{"name":"Test","status":"IN_PROGRESS"}]}`))
+	srvr := httptest.NewServer(mux)
+	defer srvr.Close()
+
+	var reported []BuildProgress
+	api := NewJobAPI(URLBuilder(srvr.URL), BasicAuthRequestor("", ""))
+	item := QueueItem{Number: 2, URL: srvr.URL + "/job/Test/2/"}
+	info, err := api.WaitUntilBuildIsComplete(context.TODO(), item, time.Millisecond, WithBuildProgress(func(p BuildProgress) {
+		reported = append(reported, p)
+	}))
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if info.Result != "SUCCESS" {
+		t.Errorf("Expected SUCCESS but got %v", info.Result)
+	}
+	if len(reported) != 2 {
+		t.Fatalf("Expected progress to be reported after every poll but got %v", reported)
+	}
+	if reported[0].Percent != 25 || reported[0].Stage != "Test" {
+		t.Errorf("Expected 25%% in stage Test but got %v%% in %q", reported[0].Percent, reported[0].Stage)
+	}
+	if reported[1].Percent != 100 || reported[1].Stage != "" {
+		t.Errorf("Expected 100%% without a stage but got %v%% in %q", reported[1].Percent, reported[1].Stage)
+	}
+}
+
+func TestJobApi_WaitUntilBuildIsComplete_ReportsProgressWithoutStagesForFreestyleJobs(t *testing.T) {
+	var reported BuildProgress
+	client, cleanupFn := jobAPITestClient("/job/Test/1/api/json", stringResponseHandleFunc(`{"number":1,"building":true}`))
+	defer cleanupFn()
+	ctx, cancelFn := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelFn()
+	client.WaitUntilBuildIsComplete(ctx, QueueItem{URL: fmt.Sprintf("%v/job/Test/1", client.URLBuilder)}, time.Millisecond,
+		WithBuildProgress(func(p BuildProgress) { reported = p }))
+
+	if reported.Info.Number != 1 || reported.Percent != -1 || reported.Stage != "" {
+		t.Errorf("Expected progress of build 1 without an estimate or stage but got %+v", reported)
+	}
+}
+
+func TestQueueAPI_WaitUntilBuildIsQueued_ReportsProgress(t *testing.T) {
+	var reasons []string
+	client, cleanupFn := queueAPITestClient("/queue/item/", responseCountCheckingHandlerFunc(t,
+		`{"why":"Waiting for next available executor"}`, queueItemWithExecutable))
+	defer cleanupFn()
+
+	item, err := client.WaitUntilBuildIsQueued(context.TODO(), 1, time.Millisecond, WithQueueProgress(func(p QueueProgress) {
+		reasons = append(reasons, p.Why)
+	}))
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if item.Number != 2 {
+		t.Errorf("Expected build number 2 but got %v", item.Number)
+	}
+	if len(reasons) != 2 || reasons[0] != "Waiting for next available executor" || reasons[1] != "" {
+		t.Errorf("Expected the reason to be reported until the build started but got %q", reasons)
+	}
+}
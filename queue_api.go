@@ -2,7 +2,9 @@ package gojenkins
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 )
@@ -14,6 +16,17 @@ type QueueStats struct {
 
 type QueueID uint32
 
+// QueuedItem is an item waiting in the jenkins queue.
+type QueuedItem struct {
+	ID       QueueID
+	TaskName string
+	// Why is the reason the item is still waiting, e.g. "Waiting for next available executor".
+	Why          string
+	InQueueSince time.Time
+	Blocked      bool
+	Stuck        bool
+}
+
 type QueueItem struct {
 	Number BuildNumber
 	URL    string
@@ -24,15 +37,27 @@ const (
 	DefaultWaitForBuildToBeQueuedTimeout = time.Duration(1 * time.Minute)
 )
 
+// ErrQueueItemCancelled is returned, wrapped with the id of the queue item, when the queue item was
+// cancelled before its build started.
+var ErrQueueItemCancelled = errors.New("queue item was cancelled")
+
 // QueueAPI is the interface to interact with Jenkins Queue api.
 type QueueAPI interface {
 	QueueStats(ctx context.Context) (QueueStats, error)
 
+	// ListQueue returns the items waiting in the queue.
+	ListQueue(ctx context.Context) ([]QueuedItem, error)
+
+	// CancelQueueItem removes the item from the queue before its build starts.
+	CancelQueueItem(ctx context.Context, id QueueID) error
+
 	// WaitUntilBuildIsQueued polls jenkins queue api until the build starts to execute.
 	// A timeout is enforced via context.
 	// If the context does not have a timeout DefaultWaitForBuildToBeQueuedTimeout is used.
 	// To not bombard jenkins after every unsuccessful call we wait for retryAfter before retrying.
-	WaitUntilBuildIsQueued(ctx context.Context, id QueueID, retryAfter time.Duration) (QueueItem, error)
+	// WithQueueProgress reports the state of the queue item after every poll.
+	// It returns an error matching ErrQueueItemCancelled if the queue item is cancelled.
+	WaitUntilBuildIsQueued(ctx context.Context, id QueueID, retryAfter time.Duration, opts ...WaitOption) (QueueItem, error)
 }
 
 func NewQueueAPI(u URLBuilder, r Requestor) queueAPI {
@@ -56,6 +81,7 @@ func (q queueAPI) QueueStats(ctx context.Context) (QueueStats, error) {
 	resp := q.requestor.Do(ctx, Request{
 		Method: http.MethodGet,
 		URL:    q.URLBuilder.JSONEndpoint("/queue"),
+		Route:  "queue/api/json",
 	})
 
 	if err := resp.VerifyAndDecode(JsonDecoder(&queueResponse)); err != nil {
@@ -70,23 +96,68 @@ func (q queueAPI) QueueStats(ctx context.Context) (QueueStats, error) {
 	return stats, nil
 }
 
-func (q queueAPI) WaitUntilBuildIsQueued(ctx context.Context, id QueueID, retryAfter time.Duration) (QueueItem, error) {
-	ctx, cancelFn := setTimeoutIfNotSet(ctx, DefaultWaitForBuildToBeQueuedTimeout)
-	defer cancelFn()
-
-	var queueItem struct {
-		Executable struct {
-			Number BuildNumber
-			URL    string
+func (q queueAPI) ListQueue(ctx context.Context) ([]QueuedItem, error) {
+	var queueResponse struct {
+		Items []struct {
+			ID   QueueID
+			Task struct {
+				Name string
+			}
+			Why          string
+			InQueueSince int64
+			Blocked      bool
+			Stuck        bool
 		}
 	}
-	url := q.URLBuilder.JSONEndpoint("queue", "item", strconv.FormatUint(uint64(id), 10))
 
+	resp := q.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    q.URLBuilder.JSONEndpoint("queue"),
+		Route:  "queue/api/json",
+	})
+
+	if err := resp.VerifyAndDecode(JsonDecoder(&queueResponse)); err != nil {
+		return nil, err
+	}
+
+	var items []QueuedItem
+	for _, item := range queueResponse.Items {
+		items = append(items, QueuedItem{
+			ID:           item.ID,
+			TaskName:     item.Task.Name,
+			Why:          item.Why,
+			InQueueSince: time.Unix(0, item.InQueueSince*int64(time.Millisecond)),
+			Blocked:      item.Blocked,
+			Stuck:        item.Stuck,
+		})
+	}
+	return items, nil
+}
+
+func (q queueAPI) CancelQueueItem(ctx context.Context, id QueueID) error {
+	resp := q.requestor.Do(ctx, Request{
+		Method: http.MethodPost,
+		URL:    q.URLBuilder.Endpoint("queue", "cancelItem"),
+		Route:  "queue/cancelItem",
+		Query:  url.Values{"id": []string{strconv.FormatUint(uint64(id), 10)}},
+	})
+	return resp.VerifyAndDecode(NoOpDecoder, statusCodeVerifier(http.StatusOK, http.StatusNoContent))
+}
+
+func (q queueAPI) WaitUntilBuildIsQueued(ctx context.Context, id QueueID, retryAfter time.Duration, opts ...WaitOption) (QueueItem, error) {
+	cfg := newWaitConfig(opts)
+	ctx, cancelFn := setTimeoutIfNotSet(ctx, DefaultWaitForBuildToBeQueuedTimeout)
+	defer cancelFn()
+
+	var queueItem QueueItem
+	var iteration int
 	err := retryUntilFalseOrError(ctx, retryAfter, func() (bool, error) {
-		resp := q.requestor.Do(ctx, Request{Method: http.MethodGet, URL: url})
-		err := resp.VerifyAndDecode(JsonDecoder(&queueItem))
-		return queueItem.Executable.Number == 0, err
+		iteration++
+		pollIteration(ctx, q.requestor, "WaitUntilBuildIsQueued", iteration)
+		var err error
+		queueItem, err = q.pollQueueItem(ctx, id, cfg)
+		return queueItem.Number == 0, err
 	})
 
-	return QueueItem(queueItem.Executable), err
+	return queueItem, err
 }
@@ -2,6 +2,7 @@ package gojenkins
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
@@ -24,6 +25,42 @@ func TestQueueApi_QueueStats(t *testing.T) {
 	}
 }
 
+func TestQueueApi_ListQueue(t *testing.T) {
+	api, cleanupFn := queueAPITestClient("/queue/api/json", stringResponseHandleFunc(queueAPIResponse))
+	defer cleanupFn()
+
+	items, err := api.ListQueue(context.TODO())
+	if err != nil {
+		t.Fatalf("Expected queue items but got error %v", err)
+	}
+	expectedItems := []QueuedItem{{
+		ID:           2,
+		TaskName:     "test-job-1",
+		Why:          "Waiting for next available executor",
+		InQueueSince: time.Unix(0, 1488421278987*int64(time.Millisecond)),
+		Stuck:        true,
+	}}
+	if !reflect.DeepEqual(expectedItems, items) {
+		t.Errorf("Expected items %v but got %v", expectedItems, items)
+	}
+}
+
+func TestQueueApi_CancelQueueItem(t *testing.T) {
+	var actualRequest *http.Request
+	api, cleanupFn := queueAPITestClient("/queue/cancelItem", func(resp http.ResponseWriter, req *http.Request) {
+		actualRequest = req
+		resp.WriteHeader(http.StatusNoContent)
+	})
+	defer cleanupFn()
+
+	if err := api.CancelQueueItem(context.TODO(), 3); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if actualRequest.Method != http.MethodPost || actualRequest.URL.Query().Get("id") != "3" {
+		t.Errorf("Expected POST with id 3 but got %v %v", actualRequest.Method, actualRequest.URL)
+	}
+}
+
 func TestQueueAPI_WaitUntilBuildIsQueued(t *testing.T) {
 	tests := map[string]struct {
 		jenkinsResponses  []string
@@ -62,6 +99,14 @@ func TestQueueAPI_WaitUntilBuildIsQueued_StopsPollingOnError(t *testing.T) {
 	}
 }
 
+func TestQueueAPI_WaitUntilBuildIsQueued_StopsPollingWhenCancelled(t *testing.T) {
+	_, err := launchAndWaitUntilBuildIsQueued(responseCountCheckingHandlerFunc(t, "{}", `{"cancelled": true, "executable": null}`), 100*time.Millisecond, 1*time.Millisecond)
+
+	if !errors.Is(err, ErrQueueItemCancelled) {
+		t.Fatalf("Expected %v but got %v", ErrQueueItemCancelled, err)
+	}
+}
+
 func TestQueueAPI_WaitUntilBuildIsQueued_TimesoutCorrectly(t *testing.T) {
 	_, err := launchAndWaitUntilBuildIsQueued(stringResponseHandleFunc("{}"), 1*time.Millisecond, 2*time.Millisecond)
 
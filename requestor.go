@@ -1,52 +1,255 @@
 package gojenkins
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
 	"strings"
 )
 
-type Requestor struct {
-	username string
-	apiKey   string
+// Requestor sends a Request to jenkins and returns its Response.
+// NewJobAPI, NewQueueAPI and NewViewAPI accept any implementation.
+type Requestor interface {
+	Do(ctx context.Context, req Request) *Response
 }
 
-func BasicAuthRequestor(username, apiKey string) Requestor {
-	return Requestor{username, apiKey}
+// RequestorFunc is an adapter to allow the use of ordinary functions as a Requestor.
+type RequestorFunc func(ctx context.Context, req Request) *Response
+
+func (f RequestorFunc) Do(ctx context.Context, req Request) *Response {
+	return f(ctx, req)
 }
 
-func (r Requestor) Do(ctx context.Context, rb Request) *Response {
-	req, err := rb.BuildHTTPRequest()
-	if err != nil {
-		return &Response{err: err}
+// Middleware wraps a Requestor to add behavior such as custom headers, auditing, caching or rate limiting.
+type Middleware func(next Requestor) Requestor
+
+// Chain wraps r with the given middlewares. The first middleware is the outermost one
+// and sees a request before all others.
+func Chain(r Requestor, middlewares ...Middleware) Requestor {
+	chained := r
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		chained = middlewares[i](chained)
 	}
-	req.SetBasicAuth(r.username, r.apiKey)
+	return chainedRequestor{Requestor: chained, base: r}
+}
 
-	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+type chainedRequestor struct {
+	Requestor
+	base Requestor
+}
 
-	if err != nil {
-		return &Response{err: err}
+func (c chainedRequestor) pollIteration(ctx context.Context, operation string, iteration int) {
+	pollIteration(ctx, c.base, operation, iteration)
+}
+
+// HTTPRequestor is the Requestor used by NewClient. It authenticates requests using its Authenticator,
+// retries transient failures and reports every attempt to its Logger and Observer.
+type HTTPRequestor struct {
+	// client sends the requests. It is built from baseClient, tlsConfig and certificates.
+	client        *http.Client
+	baseClient    *http.Client
+	tlsConfig     *tls.Config
+	certificates  *certificateReloader
+	authenticator Authenticator
+	retryPolicy   RetryPolicy
+	logger        Logger
+	observer      Observer
+	middlewares   []Middleware
+}
+
+func BasicAuthRequestor(username, apiKey string) HTTPRequestor {
+	return NewRequestor(APITokenAuthenticator(username, apiKey))
+}
+
+// NewRequestor returns a HTTPRequestor which authenticates every request using a.
+func NewRequestor(a Authenticator) HTTPRequestor {
+	return HTTPRequestor{
+		authenticator: a,
+		retryPolicy:   DefaultRetryPolicy,
+		logger:        nopLogger{},
+		observer:      nopObserver{},
 	}
+}
 
-	return &Response{err: err, response: resp}
+// WithAuthenticator returns a copy of the requestor which authenticates every request using a.
+func (r HTTPRequestor) WithAuthenticator(a Authenticator) HTTPRequestor {
+	r.authenticator = a
+	return r
+}
+
+// WithHTTPClient returns a copy of the requestor which sends requests using c instead of http.DefaultClient.
+// The TLS configuration of WithTLSConfig and WithClientCertificate is applied to a copy of its transport,
+// whichever order they are called in.
+func (r HTTPRequestor) WithHTTPClient(c *http.Client) HTTPRequestor {
+	r.baseClient = c
+	r.client = r.buildClient()
+	return r
+}
+
+// WithTLSConfig returns a copy of the requestor whose transport uses cfg, e.g. to trust a private CA.
+// The transport of the current http client is cloned so that http.DefaultTransport is never modified.
+func (r HTTPRequestor) WithTLSConfig(cfg *tls.Config) HTTPRequestor {
+	r.tlsConfig = cfg
+	r.client = r.buildClient()
+	return r
+}
+
+// WithClientCertificate returns a copy of the requestor which presents the certificate in certFile
+// and keyFile for mutual TLS. The files are reloaded when they change on disk so that rotated
+// certificates are picked up without restarting.
+func (r HTTPRequestor) WithClientCertificate(certFile, keyFile string) HTTPRequestor {
+	r.certificates = newCertificateReloader(certFile, keyFile)
+	r.client = r.buildClient()
+	return r
+}
+
+// buildClient combines the http client, TLS configuration and client certificate of the requestor,
+// so that configuring one of them never drops the others.
+func (r HTTPRequestor) buildClient() *http.Client {
+	if r.tlsConfig == nil && r.certificates == nil {
+		return r.baseClient
+	}
+
+	client := http.Client{}
+	if r.baseClient != nil {
+		client = *r.baseClient
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+	transport = transport.Clone()
+
+	cfg := new(tls.Config)
+	if r.tlsConfig != nil {
+		cfg = r.tlsConfig.Clone()
+	} else if transport.TLSClientConfig != nil {
+		cfg = transport.TLSClientConfig.Clone()
+	}
+	if r.certificates != nil {
+		cfg.Certificates = nil
+		cfg.GetClientCertificate = r.certificates.GetClientCertificate
+	}
+	transport.TLSClientConfig = cfg
+	client.Transport = transport
+	return &client
+}
+
+// WithRetryPolicy returns a copy of the requestor which retries transient failures according to p.
+func (r HTTPRequestor) WithRetryPolicy(p RetryPolicy) HTTPRequestor {
+	r.retryPolicy = p
+	return r
+}
+
+// WithLogger returns a copy of the requestor which traces requests and reports errors to l.
+func (r HTTPRequestor) WithLogger(l Logger) HTTPRequestor {
+	if l == nil {
+		l = nopLogger{}
+	}
+	r.logger = l
+	return r
+}
+
+// WithObserver returns a copy of the requestor which notifies o about every request and poll.
+func (r HTTPRequestor) WithObserver(o Observer) HTTPRequestor {
+	if o == nil {
+		o = nopObserver{}
+	}
+	r.observer = o
+	return r
+}
+
+// WithMiddleware returns a copy of the requestor which passes every request through the given middlewares.
+// Middlewares are applied in order, after the ones already configured, and see every request once
+// before it is retried.
+func (r HTTPRequestor) WithMiddleware(middlewares ...Middleware) HTTPRequestor {
+	r.middlewares = append(r.middlewares[:len(r.middlewares):len(r.middlewares)], middlewares...)
+	return r
+}
+
+func (r HTTPRequestor) Do(ctx context.Context, req Request) *Response {
+	authenticator, logger, observer := r.authenticator, r.logger, r.observer
+	if authenticator == nil {
+		authenticator = AnonymousAuthenticator()
+	}
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	if observer == nil {
+		observer = nopObserver{}
+	}
+
+	middlewares := append(r.middlewares[:len(r.middlewares):len(r.middlewares)],
+		retryMiddleware(r.retryPolicy, logger, observer),
+		Observe(observer),
+		Logging(logger),
+		Authenticate(authenticator))
+	return Chain(sender(r.client), middlewares...).Do(withHTTPClient(ctx, r.client), req)
+}
+
+func (r HTTPRequestor) pollIteration(ctx context.Context, operation string, iteration int) {
+	if r.observer != nil {
+		r.observer.PollIteration(ctx, operation, iteration)
+	}
+}
+
+// sender returns a Requestor which sends requests using client, or http.DefaultClient if client is nil.
+func sender(client *http.Client) Requestor {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return RequestorFunc(func(ctx context.Context, rb Request) *Response {
+		req, err := rb.BuildHTTPRequest()
+		if err != nil {
+			return &Response{err: err}
+		}
+
+		resp, err := client.Do(req.WithContext(ctx))
+
+		if err != nil {
+			return &Response{err: err}
+		}
+
+		return &Response{err: err, response: resp}
+	})
+}
+
+// BasicAuth returns a Middleware that authenticates every request using basic auth.
+func BasicAuth(username, apiKey string) Middleware {
+	return Authenticate(APITokenAuthenticator(username, apiKey))
+}
+
+// pollIteration notifies the observer of r, if any, that a wait operation is polling jenkins.
+func pollIteration(ctx context.Context, r Requestor, operation string, iteration int) {
+	if p, ok := r.(interface {
+		pollIteration(context.Context, string, int)
+	}); ok {
+		p.pollIteration(ctx, operation, iteration)
+	}
 }
 
 const (
 	ContentTypeJSON           = "application/json"
 	ContentTypeFormURLEncoded = "application/x-www-form-urlencoded"
+	ContentTypeXML            = "text/xml"
 )
 
 type Request struct {
-	Method      string
-	URL         string
+	Method string
+	URL    string
+	// Route is a low cardinality template of the URL path, e.g. job/{name}/api/json, reported to the Observer.
+	// The URL path is reported if Route is empty.
+	Route       string
 	Query       url.Values
+	Header      http.Header
 	ContentType string
 	Body        io.Reader
 }
@@ -60,6 +263,9 @@ func (r Request) BuildHTTPRequest() (*http.Request, error) {
 		req.URL.RawQuery = r.Query.Encode()
 	}
 
+	for name, values := range r.Header {
+		req.Header[name] = append([]string(nil), values...)
+	}
 	if r.ContentType == "" {
 		r.ContentType = ContentTypeJSON
 	}
@@ -76,6 +282,24 @@ func JsonDecoder(v interface{}) Decoder {
 	}
 }
 
+// XMLDecoder decodes xml into v. Jenkins declares XML 1.1 for config files, which encoding/xml
+// rejects, so the declaration is skipped.
+func XMLDecoder(v interface{}) Decoder {
+	return func(r io.Reader) error {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		data = bytes.TrimSpace(data)
+		if bytes.HasPrefix(data, []byte("<?xml")) {
+			if end := bytes.Index(data, []byte("?>")); end >= 0 {
+				data = data[end+len("?>"):]
+			}
+		}
+		return xml.Unmarshal(data, v)
+	}
+}
+
 func NoOpDecoder(r io.Reader) error {
 	_, err := io.Copy(ioutil.Discard, r)
 	return err
@@ -85,25 +309,86 @@ type Verifier func(*http.Response) error
 
 var StatusOKVerifier = HTTPStatusCodeVerifier(http.StatusOK)
 
+// StatusOKOrFoundVerifier accepts the redirect jenkins answers actions with, whether the http client
+// followed it or not.
+var StatusOKOrFoundVerifier = statusCodeVerifier(http.StatusOK, http.StatusFound)
+
 func HTTPStatusCodeVerifier(statusCode int) Verifier {
 	return func(resp *http.Response) error {
 		if resp.StatusCode != statusCode {
-			return fmt.Errorf("Unexpected status code %v. Expected %v", resp.StatusCode, statusCode)
+			return StatusCodeError{StatusCode: resp.StatusCode, Expected: statusCode}
 		}
 		return nil
 	}
 }
 
+// statusCodeVerifier accepts any of the given status codes. Jenkins answers some actions with a
+// redirect which is followed, or with no content, depending on its version.
+func statusCodeVerifier(statusCodes ...int) Verifier {
+	return func(resp *http.Response) error {
+		for _, statusCode := range statusCodes {
+			if resp.StatusCode == statusCode {
+				return nil
+			}
+		}
+		return StatusCodeError{StatusCode: resp.StatusCode, Expected: statusCodes[0]}
+	}
+}
+
+// StatusCodeError is returned when jenkins responds with an unexpected status code.
+type StatusCodeError struct {
+	StatusCode int
+	Expected   int
+}
+
+func (e StatusCodeError) Error() string {
+	return fmt.Sprintf("Unexpected status code %v. Expected %v", e.StatusCode, e.Expected)
+}
+
 type Response struct {
 	err                  error
 	response             *http.Response
 	isResponseBodyClosed bool
+	logger               Logger
+}
+
+// NewResponse returns a Response for a custom Requestor. Either resp or err is expected to be set.
+func NewResponse(resp *http.Response, err error) *Response {
+	return &Response{err: err, response: resp}
+}
+
+// HTTPResponse returns the underlying http response, which is nil if the request failed.
+func (r *Response) HTTPResponse() *http.Response {
+	return r.response
+}
+
+// Err returns the error that occurred while sending the request, if any.
+func (r *Response) Err() error {
+	return r.err
+}
+
+// isRetryable reports whether the request failed with a connection error or a retryable status code.
+func (r *Response) isRetryable() bool {
+	if r.err != nil || r.response == nil {
+		return isTransient(r.err)
+	}
+	return isRetryableStatusCode(r.response.StatusCode)
+}
+
+// discard drains and closes the response body so that the underlying connection can be reused.
+func (r *Response) discard() {
+	if r.response == nil || r.isResponseBodyClosed {
+		return
+	}
+	r.isResponseBodyClosed = true
+	NoOpDecoder(r.response.Body)
+	r.response.Body.Close()
 }
 
 func (r *Response) VerifyAndDecode(decoder Decoder, verifiers ...Verifier) error {
 	err := r.verifyAndDecode(decoder, verifiers...)
-	if err != nil {
-		log.Println(err)
+	if err != nil && r.logger != nil {
+		r.logger.Error("jenkins request failed", "error", err)
 	}
 	return err
 }
@@ -150,3 +435,14 @@ func (es errorSlice) Error() string {
 	}
 	return strings.Join(errs, " : ")
 }
+
+func (es errorSlice) Unwrap() []error {
+	return es
+}
+
+func cloneHeader(h http.Header) http.Header {
+	if h == nil {
+		return make(http.Header)
+	}
+	return h.Clone()
+}
@@ -0,0 +1,158 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var fastRetryPolicy = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, Multiplier: 2}
+
+func TestRequestor_Do_RetriesTransientFailures(t *testing.T) {
+	tests := map[string]struct {
+		method        string
+		policy        RetryPolicy
+		statusCodes   []int
+		expectedCalls int
+		expectedError bool
+	}{
+		"should retry retryable status codes until success": {
+			method:        http.MethodGet,
+			policy:        fastRetryPolicy,
+			statusCodes:   []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			expectedCalls: 3,
+		},
+		"should give up after max attempts": {
+			method:        http.MethodGet,
+			policy:        fastRetryPolicy,
+			statusCodes:   []int{http.StatusGatewayTimeout, http.StatusGatewayTimeout, http.StatusGatewayTimeout},
+			expectedCalls: 3,
+			expectedError: true,
+		},
+		"should not retry non retryable status codes": {
+			method:        http.MethodGet,
+			policy:        fastRetryPolicy,
+			statusCodes:   []int{http.StatusNotFound},
+			expectedCalls: 1,
+			expectedError: true,
+		},
+		"should not retry non idempotent requests": {
+			method:        http.MethodPost,
+			policy:        fastRetryPolicy,
+			statusCodes:   []int{http.StatusServiceUnavailable},
+			expectedCalls: 1,
+			expectedError: true,
+		},
+		"should retry non idempotent requests when opted in": {
+			method:        http.MethodPost,
+			policy:        RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, RetryNonIdempotent: true},
+			statusCodes:   []int{http.StatusTooManyRequests, http.StatusOK},
+			expectedCalls: 2,
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var calls int
+			srvr := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				if calls >= len(testdata.statusCodes) {
+					t.Fatalf("Expected %v calls but was called %v times", len(testdata.statusCodes), calls+1)
+				}
+				if body, _ := ioutil.ReadAll(req.Body); string(body) != "body" {
+					t.Errorf("Expected request body to be replayed but got %q", body)
+				}
+				resp.Header().Set("Retry-After", "0")
+				resp.WriteHeader(testdata.statusCodes[calls])
+				calls++
+			}))
+			defer srvr.Close()
+
+			requestor := BasicAuthRequestor("", "").WithRetryPolicy(testdata.policy)
+			err := requestor.
+				Do(context.TODO(), Request{Method: testdata.method, URL: srvr.URL, Body: strings.NewReader("body")}).
+				VerifyAndDecode(NoOpDecoder)
+
+			if (err != nil) != testdata.expectedError {
+				t.Errorf("Expected error %v but got %v", testdata.expectedError, err)
+			}
+			if calls != testdata.expectedCalls {
+				t.Errorf("Expected %v calls but got %v", testdata.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestChain_AppliesMiddlewaresInOrder(t *testing.T) {
+	var calls []string
+	recordingMiddleware := func(name string) Middleware {
+		return func(next Requestor) Requestor {
+			return RequestorFunc(func(ctx context.Context, req Request) *Response {
+				calls = append(calls, name)
+				return next.Do(ctx, req)
+			})
+		}
+	}
+	base := RequestorFunc(func(ctx context.Context, req Request) *Response {
+		calls = append(calls, "base")
+		return NewResponse(nil, errors.New("not sent"))
+	})
+
+	Chain(base, recordingMiddleware("first"), recordingMiddleware("second")).Do(context.TODO(), Request{})
+
+	expectedCalls := []string{"first", "second", "base"}
+	if !reflect.DeepEqual(expectedCalls, calls) {
+		t.Errorf("Expected calls %v but got %v", expectedCalls, calls)
+	}
+}
+
+func TestHTTPRequestor_WithMiddleware_CanAddHeaders(t *testing.T) {
+	var actualRequest *http.Request
+	srvr := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		actualRequest = req
+		fmt.Fprint(resp, listJobsResponse)
+	}))
+	defer srvr.Close()
+
+	auditHeader := func(next Requestor) Requestor {
+		return RequestorFunc(func(ctx context.Context, req Request) *Response {
+			req.Header = http.Header{"X-Audit-User": []string{"tester"}}
+			return next.Do(ctx, req)
+		})
+	}
+	api := NewViewAPI(URLBuilder(srvr.URL), BasicAuthRequestor("user", "apiKey").WithMiddleware(auditHeader))
+
+	if _, err := api.ListJobNames(context.TODO(), "test-view"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if header := actualRequest.Header.Get("X-Audit-User"); header != "tester" {
+		t.Errorf("Expected X-Audit-User header to be tester but was %v", header)
+	}
+	if username, apiKey, _ := actualRequest.BasicAuth(); username != "user" || apiKey != "apiKey" {
+		t.Errorf("Expected basic auth user:apiKey but got %v:%v", username, apiKey)
+	}
+}
+
+func TestNewViewAPI_AcceptsAnyRequestor(t *testing.T) {
+	requestor := RequestorFunc(func(ctx context.Context, req Request) *Response {
+		return NewResponse(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(listJobsResponse)),
+		}, nil)
+	})
+
+	names, err := NewViewAPI(URLBuilder("http://jenkins"), requestor).ListJobNames(context.TODO(), "test-view")
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(names) != 3 {
+		t.Errorf("Expected 3 job names but got %v", names)
+	}
+}
@@ -1,17 +1,151 @@
 package gojenkins
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
 	"time"
 )
 
+// RetryPolicy controls how a Requestor retries requests that failed with a transient error.
+// Timeouts, refused or reset connections and responses with status 429, 502, 503 or 504 are considered transient.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made for a request, including the first one.
+	// A value of 1 or less disables retries.
+	MaxAttempts int
+
+	// InitialBackoff is the time waited before the first retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the exponentially growing wait between retries and the wait requested by jenkins
+	// with a Retry-After header.
+	MaxBackoff time.Duration
+
+	// Multiplier is the factor by which the backoff grows after every retry.
+	Multiplier float64
+
+	// RetryNonIdempotent allows retrying requests whose method is not idempotent, e.g. POST.
+	// Retrying ScheduleBuild could schedule the same build twice, so this is disabled by default.
+	RetryNonIdempotent bool
+}
+
+var (
+	// DefaultRetryPolicy is used by BasicAuthRequestor unless another policy is configured.
+	DefaultRetryPolicy = RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+	}
+
+	// NoRetryPolicy makes a single attempt for every request.
+	NoRetryPolicy = RetryPolicy{MaxAttempts: 1}
+)
+
+func (p RetryPolicy) maxAttempts(method string) int {
+	if p.MaxAttempts < 1 || (!isIdempotent(method) && !p.RetryNonIdempotent) {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the time to wait before the given retry attempt (starting at 1).
+// Half of the backoff is randomized so that concurrent clients do not retry in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.Multiplier
+		if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := int64(d / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatusCode(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header which is either in seconds or a HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// isTransient reports whether err is caused by a timeout, a refused or reset connection, a connection
+// closed mid response or a retryable status code. Other errors, e.g. an unsupported scheme or a
+// certificate that cannot be verified, fail the same way every time and are not transient.
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var statusErr StatusCodeError
+	if errors.As(err, &statusErr) {
+		return isRetryableStatusCode(statusErr.StatusCode)
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryUntilFalseOrError calls fn every d until it returns false or a non transient error.
+// Transient errors are retried until the context is done, in which case the last of them is
+// returned wrapped with the error of the context.
 func retryUntilFalseOrError(ctx context.Context, d time.Duration, fn func() (bool, error)) error {
 	shouldRetry, err := fn()
 
-	for err == nil && shouldRetry {
+	for (err == nil && shouldRetry) || isTransient(err) {
 		afterChan := time.After(d)
 		select {
 		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("%w: last error: %w", ctx.Err(), err)
+			}
 			return ctx.Err()
 		case <-afterChan:
 			shouldRetry, err = fn()
@@ -19,3 +153,85 @@ func retryUntilFalseOrError(ctx context.Context, d time.Duration, fn func() (boo
 	}
 	return err
 }
+
+// Retry returns a Middleware that retries requests which failed with a transient error according to p.
+func Retry(p RetryPolicy) Middleware {
+	return retryMiddleware(p, nopLogger{}, nopObserver{})
+}
+
+func retryMiddleware(p RetryPolicy, logger Logger, observer Observer) Middleware {
+	return func(next Requestor) Requestor {
+		return RequestorFunc(func(ctx context.Context, req Request) *Response {
+			maxAttempts := p.maxAttempts(req.Method)
+			if withoutRetriesFromContext(ctx) {
+				maxAttempts = 1
+			}
+
+			var body []byte
+			if maxAttempts > 1 && req.Body != nil {
+				var err error
+				if body, err = ioutil.ReadAll(req.Body); err != nil {
+					return &Response{err: err}
+				}
+			}
+
+			for attempt := 1; ; attempt++ {
+				if body != nil {
+					req.Body = bytes.NewReader(body)
+				}
+				resp := next.Do(withAttempt(ctx, attempt), req)
+				if attempt >= maxAttempts || !resp.isRetryable() {
+					return resp
+				}
+
+				wait, ok := retryAfter(resp.response)
+				if !ok {
+					wait = p.backoff(attempt)
+				} else if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+					wait = p.MaxBackoff
+				}
+				if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+					return resp
+				}
+				resp.discard()
+
+				info := requestInfo(req, attempt)
+				logger.Debug("jenkins request will be retried", "method", info.Method, "url", info.URL, "attempt", attempt, "wait", wait)
+				observer.RequestRetried(ctx, info, wait)
+
+				select {
+				case <-ctx.Done():
+					return &Response{err: ctx.Err()}
+				case <-time.After(wait):
+				}
+			}
+		})
+	}
+}
+
+type attemptKey struct{}
+
+func withAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// attemptFromContext returns the attempt number set by the Retry middleware, which defaults to 1.
+func attemptFromContext(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
+
+type withoutRetriesKey struct{}
+
+// withoutRetries returns a copy of ctx whose requests are not retried by the Retry middleware, for
+// callers which have to see every failure, e.g. to notice jenkins going down.
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutRetriesKey{}, true)
+}
+
+func withoutRetriesFromContext(ctx context.Context) bool {
+	withoutRetries, _ := ctx.Value(withoutRetriesKey{}).(bool)
+	return withoutRetries
+}
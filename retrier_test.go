@@ -2,7 +2,15 @@ package gojenkins
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -105,3 +113,152 @@ func (mf *multiFunc) ValidateCalls() {
 		mf.t.Errorf("Expected call count 2 but was %v", mf.count)
 	}
 }
+
+func TestRetryUntilFalseOrError_RetriesTransientErrors(t *testing.T) {
+	transientErr := errorSlice{StatusCodeError{StatusCode: 503, Expected: 200}}
+	mf := newMultiFunc(t, func() (bool, error) { return false, transientErr }, doneFunc)
+
+	err := retryUntilFalseOrError(context.Background(), 1*time.Millisecond, mf.Fn)
+
+	if err != nil {
+		t.Errorf("Expected no error but got %v", err)
+	}
+	mf.ValidateCalls()
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 2}
+
+	for attempt, max := range map[int]time.Duration{1: 100, 2: 200, 3: 300, 10: 300} {
+		max *= time.Millisecond
+		if backoff := policy.backoff(attempt); backoff < max/2 || backoff > max {
+			t.Errorf("Expected backoff for attempt %v to be between %v and %v but got %v", attempt, max/2, max, backoff)
+		}
+	}
+}
+
+func TestRetryUntilFalseOrError_WrapsLastErrorWhenContextIsDone(t *testing.T) {
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelFn()
+	transientErr := errorSlice{StatusCodeError{StatusCode: 503, Expected: 200}}
+
+	err := retryUntilFalseOrError(ctx, 1*time.Millisecond, func() (bool, error) { return false, transientErr })
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v but got %v", context.DeadlineExceeded, err)
+	}
+	if !hasStatusCode(err, 503) {
+		t.Errorf("Expected the last error to be wrapped but got %v", err)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"retryable status code": {
+			err:      errorSlice{StatusCodeError{StatusCode: 503, Expected: 200}},
+			expected: true,
+		},
+		"not retryable status code": {
+			err: errorSlice{StatusCodeError{StatusCode: 404, Expected: 200}},
+		},
+		"refused connection": {
+			err:      &url.Error{Op: "Get", URL: "http://jenkins", Err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}},
+			expected: true,
+		},
+		"reset connection": {
+			err:      &url.Error{Op: "Get", URL: "http://jenkins", Err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}},
+			expected: true,
+		},
+		"connection closed mid response": {
+			err:      &url.Error{Op: "Get", URL: "http://jenkins", Err: io.ErrUnexpectedEOF},
+			expected: true,
+		},
+		"timeout": {
+			err:      &url.Error{Op: "Get", URL: "http://jenkins", Err: &net.DNSError{IsTimeout: true}},
+			expected: true,
+		},
+		"unsupported scheme": {
+			err: &url.Error{Op: "Get", URL: "ftp://jenkins", Err: errors.New("unsupported protocol scheme \"ftp\"")},
+		},
+		"certificate cannot be verified": {
+			err: &url.Error{Op: "Get", URL: "https://jenkins", Err: x509.UnknownAuthorityError{}},
+		},
+		"context canceled": {
+			err: &url.Error{Op: "Get", URL: "http://jenkins", Err: context.Canceled},
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			if actual := isTransient(testdata.err); actual != testdata.expected {
+				t.Errorf("Expected %v but got %v", testdata.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRequestor_Do_DoesNotRetryUnsupportedScheme(t *testing.T) {
+	observer := &recordingObserver{}
+	requestor := BasicAuthRequestor("", "").WithRetryPolicy(fastRetryPolicy).WithObserver(observer)
+
+	err := requestor.Do(context.TODO(), Request{Method: http.MethodGet, URL: "ftp://jenkins/api/json"}).VerifyAndDecode(NoOpDecoder)
+
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if observer.retries != 0 {
+		t.Errorf("Expected no retries but got %v", observer.retries)
+	}
+}
+
+func TestRequestor_Do_BoundsRetryAfter(t *testing.T) {
+	tests := map[string]struct {
+		policy        RetryPolicy
+		timeout       time.Duration
+		expectedCalls int
+	}{
+		"should wait at most max backoff": {
+			policy:        RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+			timeout:       time.Hour,
+			expectedCalls: 2,
+		},
+		"should not wait beyond the deadline": {
+			policy:        RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+			timeout:       time.Second,
+			expectedCalls: 1,
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var calls int
+			srvr := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+				calls++
+				resp.Header().Set("Retry-After", "86400")
+				resp.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer srvr.Close()
+
+			ctx, cancelFn := context.WithTimeout(context.Background(), testdata.timeout)
+			defer cancelFn()
+			start := time.Now()
+			err := BasicAuthRequestor("", "").
+				WithRetryPolicy(testdata.policy).
+				Do(ctx, Request{Method: http.MethodGet, URL: srvr.URL}).
+				VerifyAndDecode(NoOpDecoder)
+
+			if !hasStatusCode(err, http.StatusServiceUnavailable) {
+				t.Errorf("Expected status code %v but got %v", http.StatusServiceUnavailable, err)
+			}
+			if calls != testdata.expectedCalls {
+				t.Errorf("Expected %v calls but got %v", testdata.expectedCalls, calls)
+			}
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("Expected Retry-After to be bounded but waited %v", elapsed)
+			}
+		})
+	}
+}
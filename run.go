@@ -0,0 +1,136 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+)
+
+const (
+	// DefaultRunPollInterval is how often Run polls jenkins unless WithPollInterval is given.
+	DefaultRunPollInterval = 5 * time.Second
+
+	// runCleanupTimeout bounds cancelling the queue item or aborting the build once the context of Run is done.
+	runCleanupTimeout = 30 * time.Second
+)
+
+// RunOption configures Client.Run.
+type RunOption func(*runConfig)
+
+type runConfig struct {
+	pollInterval time.Duration
+	queueTimeout time.Duration
+	cancelOnDone bool
+	waitOpts     []WaitOption
+}
+
+// WithPollInterval configures how often Run polls jenkins while waiting.
+func WithPollInterval(d time.Duration) RunOption {
+	return func(c *runConfig) {
+		c.pollInterval = d
+	}
+}
+
+// WithQueueTimeout bounds how long Run waits for the build to leave the queue, independently of the
+// timeout of the whole run. By default the build can wait in the queue until the run times out.
+func WithQueueTimeout(d time.Duration) RunOption {
+	return func(c *runConfig) {
+		c.queueTimeout = d
+	}
+}
+
+// WithCancelOnDone configures Run to cancel the queue item, or abort the build once it started,
+// when the context is cancelled or times out, or the queue timeout expires. By default the build is left running.
+func WithCancelOnDone() RunOption {
+	return func(c *runConfig) {
+		c.cancelOnDone = true
+	}
+}
+
+// WithWaitOptions passes opts to WaitUntilBuildIsQueued and WaitUntilBuildIsComplete, e.g. to report progress.
+func WithWaitOptions(opts ...WaitOption) RunOption {
+	return func(c *runConfig) {
+		c.waitOpts = append(c.waitOpts, opts...)
+	}
+}
+
+// RunResult describes the lifecycle of a build started by Client.Run.
+type RunResult struct {
+	QueueID QueueID
+	// Item is the started build, whose Number is 0 if the build did not leave the queue.
+	Item  QueueItem
+	Build BuildInfo
+
+	// QueueWait is the time from scheduling the build until it started.
+	QueueWait time.Duration
+	// Duration is the time from the build starting until it completed. Both are measured by polling
+	// and are only as precise as the poll interval.
+	Duration time.Duration
+
+	// Cancelled reports whether the queue item was cancelled or the build aborted because the context was done.
+	Cancelled bool
+}
+
+// Run schedules a build of the job, waits for it to start and complete and returns its result.
+// If the context does not have a timeout DefaultWaitForBuildToBeCompletedTimeout bounds the whole run,
+// including the time the build waits in the queue. If the queue item is cancelled, e.g. by a user,
+// an error matching ErrQueueItemCancelled is returned.
+//
+// On error the RunResult describes how far the build got.
+func (c client) Run(ctx context.Context, jobName string, params url.Values, opts ...RunOption) (RunResult, error) {
+	cfg := runConfig{pollInterval: DefaultRunPollInterval}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	// The deadline of the run also bounds waiting in the queue, so that the shorter default of
+	// WaitUntilBuildIsQueued does not give up on a build waiting for an executor.
+	ctx, cancelRunFn := setTimeoutIfNotSet(ctx, DefaultWaitForBuildToBeCompletedTimeout)
+	defer cancelRunFn()
+
+	var result RunResult
+	queueID, err := c.ScheduleBuild(ctx, jobName, params)
+	if err != nil {
+		return result, err
+	}
+	result.QueueID = queueID
+	scheduledAt := time.Now()
+
+	queueCtx, cancelFn := ctx, context.CancelFunc(func() {})
+	if cfg.queueTimeout > 0 {
+		queueCtx, cancelFn = context.WithTimeout(ctx, cfg.queueTimeout)
+	}
+	item, err := c.WaitUntilBuildIsQueued(queueCtx, queueID, cfg.pollInterval, cfg.waitOpts...)
+	queueDone := queueCtx.Err() != nil
+	cancelFn()
+	result.QueueWait = time.Since(scheduledAt)
+	if err != nil {
+		if isContextError(err) && queueDone && cfg.cancelOnDone {
+			result.Cancelled = c.cleanup(func(ctx context.Context) error { return c.CancelQueueItem(ctx, queueID) })
+		}
+		return result, err
+	}
+	result.Item = item
+	startedAt := time.Now()
+
+	result.Build, err = c.WaitUntilBuildIsComplete(ctx, item, cfg.pollInterval, cfg.waitOpts...)
+	result.Duration = time.Since(startedAt)
+	if err != nil && isContextError(err) && ctx.Err() != nil && cfg.cancelOnDone {
+		result.Cancelled = c.cleanup(func(ctx context.Context) error { return c.StopBuild(ctx, item) })
+	}
+	return result, err
+}
+
+// isContextError reports whether waiting stopped because a context was done, which is only the context
+// of the run if its Err is set too.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// cleanup runs fn with a fresh context as the context of the run is already done.
+func (c client) cleanup(fn func(ctx context.Context) error) bool {
+	ctx, cancelFn := context.WithTimeout(context.Background(), runCleanupTimeout)
+	defer cancelFn()
+	return fn(ctx) == nil
+}
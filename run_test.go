@@ -0,0 +1,155 @@
+package gojenkins_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/venkssa/gojenkins"
+	"github.com/venkssa/gojenkins/gojenkinstest"
+)
+
+func TestClient_Run(t *testing.T) {
+	srv := gojenkinstest.NewServer()
+	defer srv.Close()
+	srv.AddJob(gojenkinstest.Job{
+		Name:       "deploy",
+		Parameters: map[string]string{"region": "us-east-1"},
+		Runs:       []gojenkinstest.Run{{QueueDelay: 20 * time.Millisecond, Duration: 30 * time.Millisecond, Result: "UNSTABLE"}},
+	})
+	client := gojenkins.NewClient(srv.URL, "", "")
+
+	result, err := client.Run(context.TODO(), "deploy", url.Values{"region": []string{"eu-west-1"}},
+		gojenkins.WithPollInterval(time.Millisecond))
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if result.QueueID != 1 || result.Item.Number != 1 || result.Build.Result != "UNSTABLE" || result.Cancelled {
+		t.Errorf("Expected build 1 of queue item 1 to be UNSTABLE but got %+v", result)
+	}
+	if result.QueueWait < 20*time.Millisecond || result.Duration <= 0 {
+		t.Errorf("Expected the queue wait to be at least 20ms and a duration but got %v and %v", result.QueueWait, result.Duration)
+	}
+	if region := srv.Builds("deploy")[0].Parameters["region"]; region != "eu-west-1" {
+		t.Errorf("Expected region parameter eu-west-1 but got %v", region)
+	}
+}
+
+func TestClient_Run_CancelsQueueItemOnQueueTimeout(t *testing.T) {
+	srv := gojenkinstest.NewServer()
+	defer srv.Close()
+	srv.AddJob(gojenkinstest.Job{Name: "deploy", Runs: []gojenkinstest.Run{{QueueDelay: time.Hour}}})
+	client := gojenkins.NewClient(srv.URL, "", "")
+
+	result, err := client.Run(context.TODO(), "deploy", nil, gojenkins.WithPollInterval(time.Millisecond),
+		gojenkins.WithQueueTimeout(20*time.Millisecond), gojenkins.WithCancelOnDone())
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v but got %v", context.DeadlineExceeded, err)
+	}
+	if !result.Cancelled || result.Item.Number != 0 {
+		t.Errorf("Expected the queue item to be cancelled before it started but got %+v", result)
+	}
+	if items, _ := client.ListQueue(context.TODO()); len(items) != 0 {
+		t.Errorf("Expected an empty queue but got %v", items)
+	}
+}
+
+func TestClient_Run_AbortsBuildOnCancellation(t *testing.T) {
+	srv := gojenkinstest.NewServer()
+	defer srv.Close()
+	srv.AddJob(gojenkinstest.Job{Name: "deploy", Runs: []gojenkinstest.Run{{Duration: time.Hour}}})
+	client := gojenkins.NewClient(srv.URL, "", "")
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelFn()
+	result, err := client.Run(ctx, "deploy", nil, gojenkins.WithPollInterval(time.Millisecond), gojenkins.WithCancelOnDone())
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v but got %v", context.DeadlineExceeded, err)
+	}
+	if !result.Cancelled || result.Item.Number != 1 {
+		t.Errorf("Expected build 1 to be aborted but got %+v", result)
+	}
+	if builds, _ := client.GetBuilds(context.TODO(), "deploy", 0, 1); len(builds) != 1 || builds[0].Result != "ABORTED" {
+		t.Errorf("Expected the build to be ABORTED but got %v", builds)
+	}
+}
+
+func TestClient_Run_LeavesBuildRunningByDefault(t *testing.T) {
+	srv := gojenkinstest.NewServer()
+	defer srv.Close()
+	srv.AddJob(gojenkinstest.Job{Name: "deploy", Runs: []gojenkinstest.Run{{Duration: time.Hour}}})
+	client := gojenkins.NewClient(srv.URL, "", "")
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelFn()
+	result, err := client.Run(ctx, "deploy", nil, gojenkins.WithPollInterval(time.Millisecond))
+
+	if !errors.Is(err, context.DeadlineExceeded) || result.Cancelled {
+		t.Errorf("Expected %v without cancelling but got %v and %+v", context.DeadlineExceeded, err, result)
+	}
+	if builds, _ := client.GetBuilds(context.TODO(), "deploy", 0, 1); len(builds) != 1 || builds[0].Result != "" {
+		t.Errorf("Expected the build to still be running but got %v", builds)
+	}
+}
+
+func TestClient_Run_WaitsInQueueUntilTheRunTimesOut(t *testing.T) {
+	srv := gojenkinstest.NewServer()
+	defer srv.Close()
+	srv.AddJob(gojenkinstest.Job{Name: "deploy", Runs: []gojenkinstest.Run{{QueueDelay: 20 * time.Millisecond}}})
+	var queueDeadlines []time.Duration
+	recordDeadline := func(next gojenkins.Requestor) gojenkins.Requestor {
+		return gojenkins.RequestorFunc(func(ctx context.Context, req gojenkins.Request) *gojenkins.Response {
+			if deadline, ok := ctx.Deadline(); ok && req.Route == "queue/item/{id}/api/json" {
+				queueDeadlines = append(queueDeadlines, time.Until(deadline))
+			}
+			return next.Do(ctx, req)
+		})
+	}
+	client := gojenkins.NewClient(srv.URL, "", "", gojenkins.WithMiddleware(recordDeadline))
+
+	_, err := client.Run(context.TODO(), "deploy", nil, gojenkins.WithPollInterval(time.Millisecond), gojenkins.WithCancelOnDone())
+
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if len(queueDeadlines) == 0 {
+		t.Fatal("Expected the queue item to be polled")
+	}
+	for _, remaining := range queueDeadlines {
+		if remaining <= gojenkins.DefaultWaitForBuildToBeQueuedTimeout {
+			t.Errorf("Expected the queue to be waited for until the run times out but had %v left", remaining)
+		}
+	}
+}
+
+func TestClient_Run_FailsWhenQueueItemIsCancelled(t *testing.T) {
+	srv := gojenkinstest.NewServer()
+	defer srv.Close()
+	srv.AddJob(gojenkinstest.Job{Name: "deploy", Runs: []gojenkinstest.Run{{QueueDelay: time.Hour}}})
+	client := gojenkins.NewClient(srv.URL, "", "")
+
+	go func() {
+		for {
+			if items, _ := client.ListQueue(context.TODO()); len(items) == 1 {
+				client.CancelQueueItem(context.TODO(), items[0].ID)
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFn()
+	result, err := client.Run(ctx, "deploy", nil, gojenkins.WithPollInterval(time.Millisecond))
+
+	if !errors.Is(err, gojenkins.ErrQueueItemCancelled) {
+		t.Errorf("Expected %v but got %v", gojenkins.ErrQueueItemCancelled, err)
+	}
+	if result.Item.Number != 0 {
+		t.Errorf("Expected the build not to start but got %+v", result)
+	}
+}
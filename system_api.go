@@ -0,0 +1,189 @@
+package gojenkins
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultWaitUntilReadyTimeout is the default time that the client would poll jenkins to be ready before giving up.
+	DefaultWaitUntilReadyTimeout = time.Duration(10 * time.Minute)
+)
+
+// SystemAPI is the interface to administer a Jenkins controller.
+type SystemAPI interface {
+	// QuietDown prevents new builds from starting, e.g. before maintenance. Running builds continue.
+	// The reason is shown to users and can be empty.
+	QuietDown(ctx context.Context, reason string) error
+
+	// CancelQuietDown allows builds to start again after QuietDown.
+	CancelQuietDown(ctx context.Context) error
+
+	// SafeRestart quiets jenkins down and restarts it once the running builds complete.
+	SafeRestart(ctx context.Context) error
+
+	// Restart restarts jenkins immediately, aborting running builds.
+	Restart(ctx context.Context) error
+
+	// Reload discards the loaded configuration and reloads it from disk.
+	Reload(ctx context.Context) error
+
+	// WaitUntilReady polls jenkins until it responds, e.g. while it is starting.
+	// Jenkins answering 503 while it is getting ready and refused connections are waited out.
+	// It returns at once while jenkins is still up, use WaitUntilRestarted after SafeRestart, Restart or Reload.
+	// A timeout is enforced via context.
+	// If the context does not have a timeout DefaultWaitUntilReadyTimeout is used.
+	// To not bombard jenkins after every unsuccessful call we wait for retryAfter before retrying.
+	WaitUntilReady(ctx context.Context, retryAfter time.Duration) error
+
+	// WaitUntilRestarted polls jenkins until it went down, answering 503 or refusing connections,
+	// and then until it is ready again, e.g. after SafeRestart, Restart or Reload.
+	// A timeout for both is enforced via context.
+	// If the context does not have a timeout DefaultWaitUntilReadyTimeout is used.
+	// To not bombard jenkins after every unsuccessful call we wait for retryAfter before retrying.
+	WaitUntilRestarted(ctx context.Context, retryAfter time.Duration) error
+
+	// RunScript runs the groovy script in the script console of the controller and returns its output.
+	// Jenkins reports exceptions thrown by the script as output, not as an error.
+	RunScript(ctx context.Context, script string) (string, error)
+
+	// RunScriptOnAgent runs the groovy script in the script console of the agent and returns its output.
+	// The controller itself is the agent named "(built-in)", or "(master)" before jenkins 2.307.
+	RunScriptOnAgent(ctx context.Context, agent, script string) (string, error)
+}
+
+func NewSystemAPI(u URLBuilder, r Requestor) systemAPI {
+	return systemAPI{u, r}
+}
+
+type systemAPI struct {
+	URLBuilder
+	requestor Requestor
+}
+
+// restartVerifier also accepts "Jenkins is restarting", which following the redirect of Restart ends at.
+// A 503 of any other action means it did not reach jenkins, e.g. because jenkins is still starting.
+var restartVerifier = statusCodeVerifier(http.StatusOK, http.StatusFound, http.StatusServiceUnavailable)
+
+func (s systemAPI) QuietDown(ctx context.Context, reason string) error {
+	params := url.Values{}
+	if reason != "" {
+		params.Set("reason", reason)
+	}
+	resp := s.requestor.Do(ctx, Request{
+		Method:      http.MethodPost,
+		URL:         s.URLBuilder.Endpoint("quietDown"),
+		Route:       "quietDown",
+		ContentType: ContentTypeFormURLEncoded,
+		Body:        strings.NewReader(params.Encode()),
+	})
+	return resp.VerifyAndDecode(NoOpDecoder, StatusOKOrFoundVerifier)
+}
+
+func (s systemAPI) CancelQuietDown(ctx context.Context) error {
+	return s.systemAction(ctx, "cancelQuietDown", StatusOKOrFoundVerifier)
+}
+
+func (s systemAPI) SafeRestart(ctx context.Context) error {
+	return s.systemAction(ctx, "safeRestart", StatusOKOrFoundVerifier)
+}
+
+func (s systemAPI) Restart(ctx context.Context) error {
+	return s.systemAction(ctx, "restart", restartVerifier)
+}
+
+func (s systemAPI) Reload(ctx context.Context) error {
+	return s.systemAction(ctx, "reload", StatusOKOrFoundVerifier)
+}
+
+func (s systemAPI) systemAction(ctx context.Context, action string, verifier Verifier) error {
+	resp := s.requestor.Do(ctx, Request{
+		Method: http.MethodPost,
+		URL:    s.URLBuilder.Endpoint(action),
+		Route:  action,
+	})
+	return resp.VerifyAndDecode(NoOpDecoder, verifier)
+}
+
+func (s systemAPI) WaitUntilReady(ctx context.Context, retryAfter time.Duration) error {
+	ctx, cancelFn := setTimeoutIfNotSet(ctx, DefaultWaitUntilReadyTimeout)
+	defer cancelFn()
+
+	var iteration int
+	return retryUntilFalseOrError(ctx, retryAfter, func() (bool, error) {
+		iteration++
+		pollIteration(ctx, s.requestor, "WaitUntilReady", iteration)
+		return false, s.ping(ctx)
+	})
+}
+
+func (s systemAPI) WaitUntilRestarted(ctx context.Context, retryAfter time.Duration) error {
+	ctx, cancelFn := setTimeoutIfNotSet(ctx, DefaultWaitUntilReadyTimeout)
+	defer cancelFn()
+
+	// Jenkins keeps answering for a moment after a restart was requested, or until the running
+	// builds complete after SafeRestart. Its requests are not retried so that the outage is seen.
+	var iteration int
+	err := retryUntilFalseOrError(ctx, retryAfter, func() (bool, error) {
+		iteration++
+		pollIteration(ctx, s.requestor, "WaitUntilRestarted", iteration)
+		err := s.ping(withoutRetries(ctx))
+		if err != nil && isTransient(err) {
+			return false, nil
+		}
+		return err == nil, err
+	})
+	if err != nil {
+		return err
+	}
+	return s.WaitUntilReady(ctx, retryAfter)
+}
+
+// ping requests the cheapest endpoint of jenkins.
+func (s systemAPI) ping(ctx context.Context) error {
+	return s.requestor.
+		Do(ctx, Request{
+			Method: http.MethodGet,
+			URL:    s.URLBuilder.JSONEndpoint(),
+			Route:  "api/json",
+			Query:  url.Values{"tree": []string{"mode"}},
+		}).
+		VerifyAndDecode(NoOpDecoder, StatusOKVerifier)
+}
+
+func (s systemAPI) RunScript(ctx context.Context, script string) (string, error) {
+	return s.runScript(ctx, s.URLBuilder.Endpoint("scriptText"), "scriptText", script)
+}
+
+func (s systemAPI) RunScriptOnAgent(ctx context.Context, agent, script string) (string, error) {
+	return s.runScript(ctx, s.URLBuilder.Endpoint("computer", url.PathEscape(agent), "scriptText"), "computer/{name}/scriptText", script)
+}
+
+func (s systemAPI) runScript(ctx context.Context, scriptURL, route, script string) (string, error) {
+	resp := s.requestor.Do(ctx, Request{
+		Method:      http.MethodPost,
+		URL:         scriptURL,
+		Route:       route,
+		ContentType: ContentTypeFormURLEncoded,
+		Body:        strings.NewReader(url.Values{"script": []string{script}}.Encode()),
+	})
+
+	var output string
+	if err := resp.VerifyAndDecode(textDecoder(&output)); err != nil {
+		return "", err
+	}
+	return output, nil
+}
+
+func textDecoder(s *string) Decoder {
+	return func(r io.Reader) error {
+		data, err := ioutil.ReadAll(r)
+		*s = string(data)
+		return err
+	}
+}
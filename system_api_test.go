@@ -0,0 +1,293 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSystemAPI_QuietDown(t *testing.T) {
+	var actualMethod, actualReason string
+	api, cleanupFn := systemAPITestClient("/quietDown", func(resp http.ResponseWriter, req *http.Request) {
+		// Jenkins redirects to the dashboard, which is followed with a GET.
+		if req.Method == http.MethodGet {
+			return
+		}
+		actualMethod = req.Method
+		actualReason = req.FormValue("reason")
+		resp.Header().Set("Location", req.URL.Path)
+		resp.WriteHeader(http.StatusFound)
+	})
+	defer cleanupFn()
+
+	if err := api.QuietDown(context.TODO(), "Upgrading plugins"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if actualMethod != http.MethodPost || actualReason != "Upgrading plugins" {
+		t.Errorf("Expected a POST with the reason but got %v %q", actualMethod, actualReason)
+	}
+}
+
+func TestSystemAPI_SystemActions(t *testing.T) {
+	tests := map[string]struct {
+		action       func(api systemAPI) error
+		expectedPath string
+		statusCode   int
+	}{
+		"cancel quiet down": {
+			action:       func(api systemAPI) error { return api.CancelQuietDown(context.TODO()) },
+			expectedPath: "/cancelQuietDown",
+			statusCode:   http.StatusOK,
+		},
+		"safe restart": {
+			action:       func(api systemAPI) error { return api.SafeRestart(context.TODO()) },
+			expectedPath: "/safeRestart",
+			statusCode:   http.StatusOK,
+		},
+		"restart": {
+			action:       func(api systemAPI) error { return api.Restart(context.TODO()) },
+			expectedPath: "/restart",
+			statusCode:   http.StatusServiceUnavailable,
+		},
+		"reload": {
+			action:       func(api systemAPI) error { return api.Reload(context.TODO()) },
+			expectedPath: "/reload",
+			statusCode:   http.StatusOK,
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var actualMethod string
+			api, cleanupFn := systemAPITestClient(testdata.expectedPath, func(resp http.ResponseWriter, req *http.Request) {
+				actualMethod = req.Method
+				resp.WriteHeader(testdata.statusCode)
+			})
+			defer cleanupFn()
+
+			if err := testdata.action(api); err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if actualMethod != http.MethodPost {
+				t.Errorf("Expected POST but got %v", actualMethod)
+			}
+		})
+	}
+}
+
+func TestSystemAPI_SystemActionsFailWhenUnavailable(t *testing.T) {
+	tests := map[string]struct {
+		action       func(api systemAPI) error
+		expectedPath string
+	}{
+		"quiet down": {
+			action:       func(api systemAPI) error { return api.QuietDown(context.TODO(), "") },
+			expectedPath: "/quietDown",
+		},
+		"cancel quiet down": {
+			action:       func(api systemAPI) error { return api.CancelQuietDown(context.TODO()) },
+			expectedPath: "/cancelQuietDown",
+		},
+		"safe restart": {
+			action:       func(api systemAPI) error { return api.SafeRestart(context.TODO()) },
+			expectedPath: "/safeRestart",
+		},
+		"reload": {
+			action:       func(api systemAPI) error { return api.Reload(context.TODO()) },
+			expectedPath: "/reload",
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			api, cleanupFn := systemAPITestClient(testdata.expectedPath, func(resp http.ResponseWriter, req *http.Request) {
+				resp.WriteHeader(http.StatusServiceUnavailable)
+			})
+			defer cleanupFn()
+
+			if err := testdata.action(api); !hasStatusCode(err, http.StatusServiceUnavailable) {
+				t.Errorf("Expected a 503 error but got %v", err)
+			}
+		})
+	}
+}
+
+func TestSystemAPI_SystemActionWithoutPermissionFails(t *testing.T) {
+	api, cleanupFn := systemAPITestClient("/restart", func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusForbidden)
+	})
+	defer cleanupFn()
+
+	err := api.Restart(context.TODO())
+	if !hasStatusCode(err, http.StatusForbidden) {
+		t.Errorf("Expected a 403 error but got %v", err)
+	}
+}
+
+func TestSystemAPI_WaitUntilReady(t *testing.T) {
+	tests := map[string]struct {
+		statusCodes      []int
+		expectedRequests int
+		expectedErr      bool
+	}{
+		"should return once jenkins responds": {
+			statusCodes:      []int{http.StatusOK},
+			expectedRequests: 1,
+		},
+		"should wait while jenkins is getting ready": {
+			statusCodes:      []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			expectedRequests: 3,
+		},
+		"should fail on other errors": {
+			statusCodes:      []int{http.StatusServiceUnavailable, http.StatusForbidden},
+			expectedRequests: 2,
+			expectedErr:      true,
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var requests int
+			api, cleanupFn := systemAPITestClient("/api/json", func(resp http.ResponseWriter, req *http.Request) {
+				resp.WriteHeader(testdata.statusCodes[requests])
+				requests++
+			})
+			defer cleanupFn()
+
+			err := api.WaitUntilReady(context.TODO(), time.Millisecond)
+			if (err != nil) != testdata.expectedErr {
+				t.Errorf("Expected error %v but got %v", testdata.expectedErr, err)
+			}
+			if requests != testdata.expectedRequests {
+				t.Errorf("Expected %v requests but got %v", testdata.expectedRequests, requests)
+			}
+		})
+	}
+}
+
+func TestSystemAPI_WaitUntilReadyTimesOut(t *testing.T) {
+	api, cleanupFn := systemAPITestClient("/api/json", func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer cleanupFn()
+
+	ctx, cancelFn := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelFn()
+	if err := api.WaitUntilReady(ctx, time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v but got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestSystemAPI_RestartAndWaitUntilRestarted(t *testing.T) {
+	// Jenkins is still up right after the restart is requested, then goes down and comes back.
+	statusCodes := []int{http.StatusOK, http.StatusOK, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}
+	var requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/restart", func(resp http.ResponseWriter, req *http.Request) {})
+	mux.HandleFunc("/api/json", func(resp http.ResponseWriter, req *http.Request) {
+		statusCode := http.StatusOK
+		if requests < len(statusCodes) {
+			statusCode = statusCodes[requests]
+		}
+		requests++
+		resp.WriteHeader(statusCode)
+	})
+	srvr := httptest.NewServer(mux)
+	defer srvr.Close()
+	api := NewSystemAPI(URLBuilder(srvr.URL), BasicAuthRequestor("", "").WithRetryPolicy(fastRetryPolicy))
+
+	if err := api.Restart(context.TODO()); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Second)
+	defer cancelFn()
+	if err := api.WaitUntilRestarted(ctx, time.Millisecond); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if requests != len(statusCodes) {
+		t.Errorf("Expected to wait for jenkins to go down and come back with %v requests but got %v", len(statusCodes), requests)
+	}
+}
+
+func TestSystemAPI_RunScript(t *testing.T) {
+	tests := map[string]struct {
+		run          func(api systemAPI) (string, error)
+		expectedPath string
+	}{
+		"controller": {
+			run:          func(api systemAPI) (string, error) { return api.RunScript(context.TODO(), "println(1 + 1)") },
+			expectedPath: "/scriptText",
+		},
+		"agent": {
+			run: func(api systemAPI) (string, error) {
+				return api.RunScriptOnAgent(context.TODO(), "linux-1", "println(1 + 1)")
+			},
+			expectedPath: "/computer/linux-1/scriptText",
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var actualMethod, actualScript string
+			api, cleanupFn := systemAPITestClient(testdata.expectedPath, func(resp http.ResponseWriter, req *http.Request) {
+				actualMethod = req.Method
+				actualScript = req.FormValue("script")
+				resp.Header().Set("Content-Type", "text/plain;charset=utf-8")
+				fmt.Fprint(resp, "2\n")
+			})
+			defer cleanupFn()
+
+			output, err := testdata.run(api)
+			if err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if output != "2\n" {
+				t.Errorf("Expected output %q but got %q", "2\n", output)
+			}
+			if actualMethod != http.MethodPost || actualScript != "println(1 + 1)" {
+				t.Errorf("Expected a POST of the script but got %v %q", actualMethod, actualScript)
+			}
+		})
+	}
+}
+
+func TestSystemAPI_RunScriptOnAgentEscapesTheName(t *testing.T) {
+	var actualPath string
+	api, cleanupFn := systemAPITestClient("/computer/", func(resp http.ResponseWriter, req *http.Request) {
+		actualPath = req.URL.EscapedPath()
+	})
+	defer cleanupFn()
+
+	if _, err := api.RunScriptOnAgent(context.TODO(), "linux agent/#1?", "println(1 + 1)"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if expectedPath := "/computer/linux%20agent%2F%231%3F/scriptText"; actualPath != expectedPath {
+		t.Errorf("Expected path %v but got %v", expectedPath, actualPath)
+	}
+}
+
+func TestSystemAPI_RunScriptWithoutPermissionFails(t *testing.T) {
+	api, cleanupFn := systemAPITestClient("/scriptText", func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(resp, "anonymous is missing the Overall/Administer permission")
+	})
+	defer cleanupFn()
+
+	output, err := api.RunScript(context.TODO(), "println(1 + 1)")
+	if !hasStatusCode(err, http.StatusForbidden) || output != "" {
+		t.Errorf("Expected a 403 error and no output but got %q, %v", output, err)
+	}
+}
+
+func systemAPITestClient(path string, fn http.HandlerFunc) (systemAPI, func()) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, fn)
+
+	srvr := httptest.NewServer(mux)
+	api := NewSystemAPI(URLBuilder(srvr.URL), BasicAuthRequestor("", "").WithRetryPolicy(NoRetryPolicy))
+	return api, srvr.Close
+}
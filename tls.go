@@ -0,0 +1,61 @@
+package gojenkins
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// certificateReloader loads a client certificate from disk and reloads it whenever
+// the certificate or key file is modified.
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+func newCertificateReloader(certFile, keyFile string) *certificateReloader {
+	return &certificateReloader{certFile: certFile, keyFile: keyFile}
+}
+
+// GetClientCertificate returns the certificate, reloading it if the files changed. While the files are
+// being rotated they may not form a matching pair, so the last loaded certificate is returned until they do.
+func (c *certificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	certificate, err := c.reload()
+	if err != nil && c.certificate != nil {
+		return c.certificate, nil
+	}
+	return certificate, err
+}
+
+func (c *certificateReloader) reload() (*tls.Certificate, error) {
+	certInfo, err := os.Stat(c.certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyInfo, err := os.Stat(c.keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.certificate != nil && certInfo.ModTime().Equal(c.certModTime) && keyInfo.ModTime().Equal(c.keyModTime) {
+		return c.certificate, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	c.certificate = &certificate
+	c.certModTime = certInfo.ModTime()
+	c.keyModTime = keyInfo.ModTime()
+	return c.certificate, nil
+}
@@ -0,0 +1,199 @@
+package gojenkins
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHTTPRequestor_WithClientCertificate_ReloadsRotatedCertificates(t *testing.T) {
+	var clientCommonName string
+	srvr := httptest.NewUnstartedServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		clientCommonName = req.TLS.PeerCertificates[0].Subject.CommonName
+		fmt.Fprint(resp, listJobsResponse)
+	}))
+	srvr.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srvr.StartTLS()
+	defer srvr.Close()
+
+	dir, err := ioutil.TempDir("", "gojenkins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeClientCertificate(t, certFile, keyFile, "first", time.Now().Add(-time.Hour))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srvr.Certificate())
+	requestor := BasicAuthRequestor("", "").
+		WithTLSConfig(&tls.Config{RootCAs: roots}).
+		WithClientCertificate(certFile, keyFile)
+	api := NewViewAPI(URLBuilder(srvr.URL), requestor)
+
+	for _, expectedCommonName := range []string{"first", "second"} {
+		if expectedCommonName == "second" {
+			writeClientCertificate(t, certFile, keyFile, "second", time.Now())
+			requestor.client.Transport.(*http.Transport).CloseIdleConnections()
+		}
+		if _, err := api.ListJobNames(context.TODO(), "test-view"); err != nil {
+			t.Fatalf("Expected no error but got %v", err)
+		}
+		if clientCommonName != expectedCommonName {
+			t.Errorf("Expected client certificate %v but got %v", expectedCommonName, clientCommonName)
+		}
+	}
+
+	if http.DefaultTransport.(*http.Transport).TLSClientConfig == requestor.client.Transport.(*http.Transport).TLSClientConfig {
+		t.Error("Expected http.DefaultTransport to not be modified")
+	}
+}
+
+func TestCertificateReloader_KeepsCertificateWhileFilesAreRotated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gojenkins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeClientCertificate(t, certFile, keyFile, "first", time.Now().Add(-time.Hour))
+	reloader := newCertificateReloader(certFile, keyFile)
+
+	first, err := reloader.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+
+	// The new certificate was written but the key of the first one is still in place.
+	oldKey, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeClientCertificate(t, certFile, keyFile, "second", time.Now())
+	newKey, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, oldKey, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if certificate, err := reloader.GetClientCertificate(nil); err != nil || certificate != first {
+		t.Errorf("Expected the first certificate while rotating but got %v", err)
+	}
+
+	if err := ioutil.WriteFile(keyFile, newKey, 0600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(keyFile, future, future); err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := reloader.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if leaf, _ := x509.ParseCertificate(certificate.Certificate[0]); leaf.Subject.CommonName != "second" {
+		t.Errorf("Expected the second certificate once rotated but got %v", leaf.Subject.CommonName)
+	}
+}
+
+func TestHTTPRequestor_TLSOptionsCanBeGivenInAnyOrder(t *testing.T) {
+	var clientCommonName string
+	srvr := httptest.NewUnstartedServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		clientCommonName = req.TLS.PeerCertificates[0].Subject.CommonName
+		fmt.Fprint(resp, listJobsResponse)
+	}))
+	srvr.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	srvr.StartTLS()
+	defer srvr.Close()
+
+	dir, err := ioutil.TempDir("", "gojenkins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeClientCertificate(t, certFile, keyFile, "client", time.Now())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srvr.Certificate())
+	withTLSConfig := func(r HTTPRequestor) HTTPRequestor { return r.WithTLSConfig(&tls.Config{RootCAs: roots}) }
+	withClientCertificate := func(r HTTPRequestor) HTTPRequestor { return r.WithClientCertificate(certFile, keyFile) }
+	withHTTPClient := func(r HTTPRequestor) HTTPRequestor { return r.WithHTTPClient(&http.Client{Timeout: time.Minute}) }
+
+	tests := map[string][]func(HTTPRequestor) HTTPRequestor{
+		"tls config, client certificate, http client": {withTLSConfig, withClientCertificate, withHTTPClient},
+		"client certificate, tls config, http client": {withClientCertificate, withTLSConfig, withHTTPClient},
+		"http client, client certificate, tls config": {withHTTPClient, withClientCertificate, withTLSConfig},
+	}
+
+	for testName, opts := range tests {
+		t.Run(testName, func(t *testing.T) {
+			clientCommonName = ""
+			requestor := BasicAuthRequestor("", "")
+			for _, opt := range opts {
+				requestor = opt(requestor)
+			}
+
+			if _, err := NewViewAPI(URLBuilder(srvr.URL), requestor).ListJobNames(context.TODO(), "test-view"); err != nil {
+				t.Fatalf("Expected no error but got %v", err)
+			}
+			if clientCommonName != "client" {
+				t.Errorf("Expected the client certificate to be presented but got %q", clientCommonName)
+			}
+			if requestor.client.Timeout != time.Minute {
+				t.Errorf("Expected the http client to be used but got %v", requestor.client)
+			}
+		})
+	}
+}
+
+func writeClientCertificate(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	}
+	for file, block := range files {
+		if err := ioutil.WriteFile(file, pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
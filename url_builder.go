@@ -1,6 +1,10 @@
 package gojenkins
 
-import "strings"
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
 
 const (
 	jsonEndpoint = "api/json"
@@ -12,3 +16,25 @@ func (url URLBuilder) JSONEndpoint(paths ...string) string {
 	parts := append([]string{string(url)}, append(paths, jsonEndpoint)...)
 	return strings.Join(parts, "/")
 }
+
+// Endpoint returns the url of a non JSON endpoint, e.g. job/name/1/stop.
+func (url URLBuilder) Endpoint(paths ...string) string {
+	parts := append([]string{string(url)}, paths...)
+	return strings.Join(parts, "/")
+}
+
+// BuildURL returns the url of a build of the job, e.g. to stop it with JobAPI.StopBuild. Jobs in folders
+// are named by their full name, e.g. folder/job.
+func (url URLBuilder) BuildURL(jobName string, number BuildNumber) string {
+	return url.Endpoint(jobPath(jobName, strconv.FormatUint(uint64(number), 10))...)
+}
+
+// jobPath returns the path segments of a job followed by paths. Jobs in folders are named by their full
+// name, e.g. folder/job. Names are escaped as jenkins encodes some itself, e.g. branch feature%2Flogin.
+func jobPath(fullName string, paths ...string) []string {
+	var segments []string
+	for _, name := range strings.Split(strings.Trim(fullName, "/"), "/") {
+		segments = append(segments, "job", url.PathEscape(name))
+	}
+	return append(segments, paths...)
+}
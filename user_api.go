@@ -0,0 +1,189 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Identity is the user jenkins authenticated the client as.
+type Identity struct {
+	Name string
+	// Anonymous reports that jenkins did not authenticate the client, e.g. because no credentials were given.
+	Anonymous     bool
+	Authenticated bool
+	// Authorities are the groups of the user.
+	Authorities []string
+}
+
+// User is a user known to jenkins.
+type User struct {
+	ID          string
+	FullName    string
+	Description string
+	URL         string
+	// Email is the address configured for the mailer plugin. It is only returned by GetUser.
+	Email string
+}
+
+// APIToken is an API token of a user. Its Value is only known when it is generated.
+type APIToken struct {
+	Name  string
+	UUID  string
+	Value string
+}
+
+// UserAPI is the interface to interact with Jenkins users.
+type UserAPI interface {
+	// WhoAmI returns the user the client is authenticated as. Calling it at startup fails fast if the
+	// credentials are wrong, with an error which matches ErrInvalidCredentials.
+	WhoAmI(ctx context.Context) (Identity, error)
+
+	// ListUsers returns the users known to jenkins. Jenkins computes the list in the background,
+	// so it may be incomplete on large instances.
+	ListUsers(ctx context.Context) ([]User, error)
+
+	// GetUser returns the details of a user.
+	GetUser(ctx context.Context, id string) (User, error)
+
+	// GenerateAPIToken generates a new API token for the authenticated user.
+	GenerateAPIToken(ctx context.Context, name string) (APIToken, error)
+
+	// RevokeAPIToken revokes an API token of the authenticated user.
+	RevokeAPIToken(ctx context.Context, uuid string) error
+}
+
+// ErrInvalidCredentials is returned by WhoAmI when jenkins rejects the credentials of the client.
+var ErrInvalidCredentials = errors.New("jenkins rejected the credentials")
+
+func NewUserAPI(u URLBuilder, r Requestor) userAPI {
+	return userAPI{u, r}
+}
+
+type userAPI struct {
+	URLBuilder
+	requestor Requestor
+}
+
+const apiTokenDescriptor = "jenkins.security.ApiTokenProperty"
+
+func (u userAPI) WhoAmI(ctx context.Context) (Identity, error) {
+	var identity Identity
+	resp := u.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    u.URLBuilder.JSONEndpoint("whoAmI"),
+		Route:  "whoAmI/api/json",
+	})
+
+	err := resp.VerifyAndDecode(JsonDecoder(&identity))
+	if hasStatusCode(err, http.StatusUnauthorized) {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	if err != nil {
+		return Identity{}, err
+	}
+	return identity, nil
+}
+
+func (u userAPI) ListUsers(ctx context.Context) ([]User, error) {
+	var people struct {
+		Users []struct {
+			User struct {
+				ID          string
+				FullName    string
+				Description string
+				AbsoluteURL string `json:"absoluteUrl"`
+			}
+		}
+	}
+
+	resp := u.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    u.URLBuilder.JSONEndpoint("asynchPeople"),
+		Route:  "asynchPeople/api/json",
+		Query:  url.Values{"tree": []string{"users[user[id,fullName,description,absoluteUrl]]"}},
+	})
+
+	if err := resp.VerifyAndDecode(JsonDecoder(&people)); err != nil {
+		return nil, err
+	}
+
+	var users []User
+	for _, person := range people.Users {
+		users = append(users, User{
+			ID:          person.User.ID,
+			FullName:    person.User.FullName,
+			Description: person.User.Description,
+			URL:         person.User.AbsoluteURL,
+		})
+	}
+	return users, nil
+}
+
+func (u userAPI) GetUser(ctx context.Context, id string) (User, error) {
+	var user struct {
+		ID          string
+		FullName    string
+		Description string
+		AbsoluteURL string `json:"absoluteUrl"`
+		Property    []struct {
+			Class   string `json:"_class"`
+			Address string
+		}
+	}
+
+	resp := u.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    u.URLBuilder.JSONEndpoint("user", id),
+		Route:  "user/{id}/api/json",
+	})
+
+	if err := resp.VerifyAndDecode(JsonDecoder(&user)); err != nil {
+		return User{}, err
+	}
+
+	details := User{ID: user.ID, FullName: user.FullName, Description: user.Description, URL: user.AbsoluteURL}
+	for _, property := range user.Property {
+		if property.Class == "hudson.tasks.Mailer$UserProperty" {
+			details.Email = property.Address
+		}
+	}
+	return details, nil
+}
+
+func (u userAPI) GenerateAPIToken(ctx context.Context, name string) (APIToken, error) {
+	var token struct {
+		Data struct {
+			TokenName  string
+			TokenUUID  string `json:"tokenUuid"`
+			TokenValue string
+		}
+	}
+
+	resp := u.requestor.Do(ctx, Request{
+		Method:      http.MethodPost,
+		URL:         u.URLBuilder.Endpoint("me", "descriptorByName", apiTokenDescriptor, "generateNewToken"),
+		Route:       "me/descriptorByName/" + apiTokenDescriptor + "/generateNewToken",
+		ContentType: ContentTypeFormURLEncoded,
+		Body:        strings.NewReader(url.Values{"newTokenName": []string{name}}.Encode()),
+	})
+
+	if err := resp.VerifyAndDecode(JsonDecoder(&token)); err != nil {
+		return APIToken{}, err
+	}
+	return APIToken{Name: token.Data.TokenName, UUID: token.Data.TokenUUID, Value: token.Data.TokenValue}, nil
+}
+
+func (u userAPI) RevokeAPIToken(ctx context.Context, uuid string) error {
+	resp := u.requestor.Do(ctx, Request{
+		Method:      http.MethodPost,
+		URL:         u.URLBuilder.Endpoint("me", "descriptorByName", apiTokenDescriptor, "revoke"),
+		Route:       "me/descriptorByName/" + apiTokenDescriptor + "/revoke",
+		ContentType: ContentTypeFormURLEncoded,
+		Body:        strings.NewReader(url.Values{"tokenUuid": []string{uuid}}.Encode()),
+	})
+	return resp.VerifyAndDecode(NoOpDecoder)
+}
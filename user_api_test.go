@@ -0,0 +1,187 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestUserAPI_WhoAmI(t *testing.T) {
+	tests := map[string]struct {
+		handler          http.HandlerFunc
+		expectedIdentity Identity
+		expectedErr      error
+	}{
+		"should return the authenticated user": {
+			handler:          stringResponseHandleFunc(whoAmIResponse),
+			expectedIdentity: Identity{Name: "alice", Authenticated: true, Authorities: []string{"authenticated", "release-managers"}},
+		},
+		"should report anonymous access": {
+			handler:          stringResponseHandleFunc(`{"anonymous":true,"authenticated":true,"authorities":["anonymous"],"name":"anonymous"}`),
+			expectedIdentity: Identity{Name: "anonymous", Anonymous: true, Authenticated: true, Authorities: []string{"anonymous"}},
+		},
+		"should fail with invalid credentials": {
+			handler: func(resp http.ResponseWriter, req *http.Request) {
+				resp.WriteHeader(http.StatusUnauthorized)
+			},
+			expectedErr: ErrInvalidCredentials,
+		},
+	}
+
+	for testName, testdata := range tests {
+		t.Run(testName, func(t *testing.T) {
+			api, cleanupFn := userAPITestClient("/whoAmI/api/json", testdata.handler)
+			defer cleanupFn()
+
+			identity, err := api.WhoAmI(context.TODO())
+			if !errors.Is(err, testdata.expectedErr) {
+				t.Fatalf("Expected error %v but got %v", testdata.expectedErr, err)
+			}
+			if !reflect.DeepEqual(testdata.expectedIdentity, identity) {
+				t.Errorf("Expected identity %+v but got %+v", testdata.expectedIdentity, identity)
+			}
+		})
+	}
+}
+
+func TestUserAPI_ListUsers(t *testing.T) {
+	api, cleanupFn := userAPITestClient("/asynchPeople/api/json", stringResponseHandleFunc(asynchPeopleResponse))
+	defer cleanupFn()
+
+	users, err := api.ListUsers(context.TODO())
+	if err != nil {
+		t.Fatalf("Expected users but got error %v", err)
+	}
+	expectedUsers := []User{
+		{ID: "alice", FullName: "Alice", URL: "http://localhost:8080/user/alice"},
+		{ID: "svc-deploy", FullName: "Deployment", Description: "Service account", URL: "http://localhost:8080/user/svc-deploy"},
+	}
+	if !reflect.DeepEqual(expectedUsers, users) {
+		t.Errorf("Expected users %+v but got %+v", expectedUsers, users)
+	}
+}
+
+func TestUserAPI_GetUser(t *testing.T) {
+	api, cleanupFn := userAPITestClient("/user/alice/api/json", stringResponseHandleFunc(userResponse))
+	defer cleanupFn()
+
+	user, err := api.GetUser(context.TODO(), "alice")
+	if err != nil {
+		t.Fatalf("Expected user but got error %v", err)
+	}
+	expectedUser := User{ID: "alice", FullName: "Alice", URL: "http://localhost:8080/user/alice", Email: "alice@example.com"}
+	if expectedUser != user {
+		t.Errorf("Expected user %+v but got %+v", expectedUser, user)
+	}
+}
+
+func TestUserAPI_GenerateAPIToken(t *testing.T) {
+	var actualMethod, actualName string
+	api, cleanupFn := userAPITestClient("/me/descriptorByName/jenkins.security.ApiTokenProperty/generateNewToken", func(resp http.ResponseWriter, req *http.Request) {
+		actualMethod = req.Method
+		actualName = req.FormValue("newTokenName")
+		stringResponseHandleFunc(generateNewTokenResponse)(resp, req)
+	})
+	defer cleanupFn()
+
+	token, err := api.GenerateAPIToken(context.TODO(), "ci")
+	if err != nil {
+		t.Fatalf("Expected a token but got error %v", err)
+	}
+	if actualMethod != http.MethodPost || actualName != "ci" {
+		t.Errorf("Expected a POST with the token name but got %v %q", actualMethod, actualName)
+	}
+	expectedToken := APIToken{Name: "ci", UUID: "4b3fb0e2-7a35-4b27-9cd7-7ba4c4f5e5e0", Value: "11d2d5f8a2c1a4a0b6e3f0c9d8e7f6a5b4"}
+	if expectedToken != token {
+		t.Errorf("Expected token %+v but got %+v", expectedToken, token)
+	}
+}
+
+func TestUserAPI_RevokeAPIToken(t *testing.T) {
+	var actualMethod, actualUUID string
+	api, cleanupFn := userAPITestClient("/me/descriptorByName/jenkins.security.ApiTokenProperty/revoke", func(resp http.ResponseWriter, req *http.Request) {
+		actualMethod = req.Method
+		actualUUID = req.FormValue("tokenUuid")
+		stringResponseHandleFunc(`{"status":"ok"}`)(resp, req)
+	})
+	defer cleanupFn()
+
+	if err := api.RevokeAPIToken(context.TODO(), "4b3fb0e2-7a35-4b27-9cd7-7ba4c4f5e5e0"); err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if actualMethod != http.MethodPost || actualUUID != "4b3fb0e2-7a35-4b27-9cd7-7ba4c4f5e5e0" {
+		t.Errorf("Expected a POST with the token uuid but got %v %q", actualMethod, actualUUID)
+	}
+}
+
+func userAPITestClient(path string, fn http.HandlerFunc) (userAPI, func()) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, fn)
+
+	srvr := httptest.NewServer(mux)
+	api := NewUserAPI(URLBuilder(srvr.URL), BasicAuthRequestor("", ""))
+	return api, srvr.Close
+}
+
+const whoAmIResponse = `
+{
+  "_class": "hudson.security.WhoAmI",
+  "anonymous": false,
+  "authenticated": true,
+  "authorities": ["authenticated", "release-managers"],
+  "details": null,
+  "name": "alice",
+  "toString": "UsernamePasswordAuthenticationToken [Principal=alice]"
+}
+`
+
+const asynchPeopleResponse = `
+{
+  "_class": "hudson.model.View$AsynchPeople$People",
+  "users": [{
+    "user": {
+      "absoluteUrl": "http://localhost:8080/user/alice",
+      "description": null,
+      "fullName": "Alice",
+      "id": "alice"
+    }
+  }, {
+    "user": {
+      "absoluteUrl": "http://localhost:8080/user/svc-deploy",
+      "description": "Service account",
+      "fullName": "Deployment",
+      "id": "svc-deploy"
+    }
+  }]
+}
+`
+
+const userResponse = `
+{
+  "_class": "hudson.model.User",
+  "absoluteUrl": "http://localhost:8080/user/alice",
+  "description": null,
+  "fullName": "Alice",
+  "id": "alice",
+  "property": [{
+    "_class": "jenkins.security.ApiTokenProperty"
+  }, {
+    "_class": "hudson.tasks.Mailer$UserProperty",
+    "address": "alice@example.com"
+  }]
+}
+`
+
+const generateNewTokenResponse = `
+{
+  "status": "ok",
+  "data": {
+    "tokenName": "ci",
+    "tokenUuid": "4b3fb0e2-7a35-4b27-9cd7-7ba4c4f5e5e0",
+    "tokenValue": "11d2d5f8a2c1a4a0b6e3f0c9d8e7f6a5b4"
+  }
+}
+`
@@ -3,10 +3,14 @@ package gojenkins
 import (
 	"context"
 	"net/http"
+	"net/url"
 )
 
 type ViewAPI interface {
 	ListJobNames(ctx context.Context, viewName string) ([]string, error)
+
+	// ListViewNames returns the names of all views.
+	ListViewNames(ctx context.Context) ([]string, error)
 }
 
 func NewViewAPI(u URLBuilder, r Requestor) viewAPI {
@@ -28,6 +32,7 @@ func (v viewAPI) ListJobNames(ctx context.Context, viewName string) ([]string, e
 	resp := v.requestor.Do(ctx, Request{
 		Method: http.MethodGet,
 		URL:    v.URLBuilder.JSONEndpoint("/view", viewName),
+		Route:  "view/{name}/api/json",
 	})
 
 	if err := resp.VerifyAndDecode(JsonDecoder(&jobs)); err != nil {
@@ -40,3 +45,28 @@ func (v viewAPI) ListJobNames(ctx context.Context, viewName string) ([]string, e
 	}
 	return names, nil
 }
+
+func (v viewAPI) ListViewNames(ctx context.Context) ([]string, error) {
+	var views struct {
+		Views []struct {
+			Name string
+		}
+	}
+
+	resp := v.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    v.URLBuilder.JSONEndpoint(),
+		Route:  "api/json",
+		Query:  url.Values{"tree": []string{"views[name]"}},
+	})
+
+	if err := resp.VerifyAndDecode(JsonDecoder(&views)); err != nil {
+		return []string{}, err
+	}
+
+	var names []string
+	for _, view := range views.Views {
+		names = append(names, view.Name)
+	}
+	return names, nil
+}
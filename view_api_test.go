@@ -25,6 +25,23 @@ func TestViewApi_ListJobNames(t *testing.T) {
 	}
 }
 
+func TestViewApi_ListViewNames(t *testing.T) {
+	api, cleanupFn := viewAPITestClient("/api/json", stringResponseHandleFunc(listViewsResponse))
+	defer cleanupFn()
+
+	names, err := api.ListViewNames(context.TODO())
+
+	if err != nil {
+		t.Fatalf("Expected a list of views but got error %v", err)
+	}
+
+	expectedNames := []string{"all", "test-view"}
+
+	if !reflect.DeepEqual(expectedNames, names) {
+		t.Errorf("Expected %v but got %v", expectedNames, names)
+	}
+}
+
 func viewAPITestClient(path string, fn http.HandlerFunc) (viewAPI, func()) {
 	mux := http.NewServeMux()
 	mux.HandleFunc(path, fn)
@@ -63,3 +80,19 @@ const listJobsResponse = `
   "url" : "http://testurl.com/jenkins/view/test-view/"
  }
 `
+
+const listViewsResponse = `
+{
+  "_class" : "hudson.model.Hudson",
+  "views" : [
+    {
+      "_class" : "hudson.model.AllView",
+      "name" : "all"
+    },
+    {
+      "_class" : "hudson.model.ListView",
+      "name" : "test-view"
+    }
+  ]
+}
+`
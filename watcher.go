@@ -0,0 +1,390 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// watcherBuildWindow is the number of most recent builds of a job fetched by a Watcher in one request.
+// Watched builds older than that are fetched individually.
+const watcherBuildWindow = 50
+
+// minWatcherPollTimeout is the least time a Watcher gives one poll of jenkins, however short its interval.
+const minWatcherPollTimeout = 10 * time.Second
+
+// ErrWatcherStopped is delivered to the watches of a Watcher once it is stopped.
+var ErrWatcherStopped = errors.New("watcher was stopped")
+
+// QueueUpdate is delivered by a Watcher once the build of a queue item starts or waiting for it failed,
+// e.g. with an error matching ErrQueueItemCancelled.
+type QueueUpdate struct {
+	ID   QueueID
+	Item QueueItem
+	Err  error
+}
+
+// BuildUpdate is delivered by a Watcher once a build completes or waiting for it failed.
+type BuildUpdate struct {
+	Item QueueItem
+	Info BuildInfo
+	Err  error
+}
+
+// Watcher waits for many queue items and builds at once. Instead of polling every queue item and
+// build separately, it polls the queue and the recent builds of every watched job once per interval.
+//
+// The polling stops while nothing is being watched and for good once the Watcher is stopped. Every
+// poll is bounded by ten intervals, but at least 10 seconds, so that a hung request does not hold up
+// every watch.
+type Watcher struct {
+	requestor   Requestor
+	queue       queueAPI
+	jobs        jobAPI
+	interval    time.Duration
+	pollTimeout time.Duration
+
+	// ctx is cancelled by Stop.
+	ctx      context.Context
+	cancelFn context.CancelFunc
+
+	mu      sync.Mutex
+	queued  map[*queueWatch]struct{}
+	builds  map[*buildWatch]struct{}
+	running bool
+}
+
+type queueWatch struct {
+	id   QueueID
+	cfg  waitConfig
+	ch   chan QueueUpdate
+	done chan struct{}
+}
+
+type buildWatch struct {
+	item   QueueItem
+	jobURL string
+	cfg    waitConfig
+	ch     chan BuildUpdate
+	done   chan struct{}
+}
+
+// NewWatcher returns a Watcher which polls jenkins every interval.
+func NewWatcher(u URLBuilder, r Requestor, interval time.Duration) *Watcher {
+	pollTimeout := 10 * interval
+	if pollTimeout < minWatcherPollTimeout {
+		pollTimeout = minWatcherPollTimeout
+	}
+	ctx, cancelFn := context.WithCancel(context.Background())
+	return &Watcher{
+		requestor:   r,
+		queue:       NewQueueAPI(u, r),
+		jobs:        NewJobAPI(u, r),
+		interval:    interval,
+		pollTimeout: pollTimeout,
+		ctx:         ctx,
+		cancelFn:    cancelFn,
+		queued:      make(map[*queueWatch]struct{}),
+		builds:      make(map[*buildWatch]struct{}),
+	}
+}
+
+// Stop stops polling and cancels the request in flight. Every watch, including those made
+// afterwards, receives ErrWatcherStopped.
+func (w *Watcher) Stop() {
+	w.cancelFn()
+}
+
+// WatchQueueItem returns a channel which receives a single update once the build of the queue item
+// starts, waiting fails or ctx is done, and is then closed. WithQueueProgress reports the state of
+// the queue item after every poll.
+func (w *Watcher) WatchQueueItem(ctx context.Context, id QueueID, opts ...WaitOption) <-chan QueueUpdate {
+	watch := &queueWatch{id: id, cfg: newWaitConfig(opts), ch: make(chan QueueUpdate, 1), done: make(chan struct{})}
+
+	w.mu.Lock()
+	w.queued[watch] = struct{}{}
+	w.startLocked()
+	w.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.resolveQueue(watch, QueueUpdate{ID: id, Err: ctx.Err()})
+		case <-w.ctx.Done():
+			w.resolveQueue(watch, QueueUpdate{ID: id, Err: ErrWatcherStopped})
+		case <-watch.done:
+		}
+	}()
+	return watch.ch
+}
+
+// WatchBuild returns a channel which receives a single update once the build completes,
+// waiting fails or ctx is done, and is then closed. WithBuildProgress reports the progress of the
+// build after every poll.
+func (w *Watcher) WatchBuild(ctx context.Context, item QueueItem, opts ...WaitOption) <-chan BuildUpdate {
+	watch := &buildWatch{
+		item:   item,
+		jobURL: jobURLOf(item.URL),
+		cfg:    newWaitConfig(opts),
+		ch:     make(chan BuildUpdate, 1),
+		done:   make(chan struct{}),
+	}
+
+	w.mu.Lock()
+	w.builds[watch] = struct{}{}
+	w.startLocked()
+	w.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.resolveBuild(watch, BuildUpdate{Item: item, Err: ctx.Err()})
+		case <-w.ctx.Done():
+			w.resolveBuild(watch, BuildUpdate{Item: item, Err: ErrWatcherStopped})
+		case <-watch.done:
+		}
+	}()
+	return watch.ch
+}
+
+// WaitUntilBuildIsQueued has the same semantics as QueueAPI.WaitUntilBuildIsQueued except that
+// jenkins is polled every interval of the Watcher and retryAfter is ignored.
+func (w *Watcher) WaitUntilBuildIsQueued(ctx context.Context, id QueueID, retryAfter time.Duration, opts ...WaitOption) (QueueItem, error) {
+	ctx, cancelFn := setTimeoutIfNotSet(ctx, DefaultWaitForBuildToBeQueuedTimeout)
+	defer cancelFn()
+
+	update := <-w.WatchQueueItem(ctx, id, opts...)
+	return update.Item, update.Err
+}
+
+// WaitUntilBuildIsComplete has the same semantics as JobAPI.WaitUntilBuildIsComplete except that
+// jenkins is polled every interval of the Watcher and retryAfter is ignored.
+func (w *Watcher) WaitUntilBuildIsComplete(ctx context.Context, item QueueItem, retryAfter time.Duration, opts ...WaitOption) (BuildInfo, error) {
+	ctx, cancelFn := setTimeoutIfNotSet(ctx, DefaultWaitForBuildToBeCompletedTimeout)
+	defer cancelFn()
+
+	update := <-w.WatchBuild(ctx, item, opts...)
+	return update.Info, update.Err
+}
+
+// startLocked starts polling unless it is already running or the Watcher was stopped. It is called with w.mu held.
+func (w *Watcher) startLocked() {
+	if w.running || w.ctx.Err() != nil {
+		return
+	}
+	w.running = true
+	go w.run()
+}
+
+func (w *Watcher) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for iteration := 1; ; iteration++ {
+		pollIteration(w.ctx, w.requestor, "Watcher", iteration)
+		ctx, cancelFn := context.WithTimeout(w.ctx, w.pollTimeout)
+		w.poll(ctx)
+		cancelFn()
+
+		select {
+		case <-w.ctx.Done():
+		case <-ticker.C:
+		}
+		w.mu.Lock()
+		if w.ctx.Err() != nil || (len(w.queued) == 0 && len(w.builds) == 0) {
+			w.running = false
+			w.mu.Unlock()
+			return
+		}
+		w.mu.Unlock()
+	}
+}
+
+func (w *Watcher) poll(ctx context.Context) {
+	w.mu.Lock()
+	queued := make([]*queueWatch, 0, len(w.queued))
+	for watch := range w.queued {
+		queued = append(queued, watch)
+	}
+	builds := make(map[string][]*buildWatch)
+	for watch := range w.builds {
+		builds[watch.jobURL] = append(builds[watch.jobURL], watch)
+	}
+	w.mu.Unlock()
+
+	w.pollQueue(ctx, queued)
+	for jobURL, watches := range builds {
+		w.pollBuilds(ctx, jobURL, watches)
+	}
+}
+
+// pollQueue fetches the queue once and only looks up the items that left it.
+func (w *Watcher) pollQueue(ctx context.Context, watches []*queueWatch) {
+	if len(watches) == 0 {
+		return
+	}
+
+	waiting, err := w.waitingQueueIDs(ctx)
+	if err != nil {
+		if !retryablePollError(ctx, err) {
+			for _, watch := range watches {
+				w.resolveQueue(watch, QueueUpdate{ID: watch.id, Err: err})
+			}
+		}
+		return
+	}
+
+	for _, watch := range watches {
+		if why, ok := waiting[watch.id]; ok {
+			if watch.cfg.queueProgress != nil {
+				watch.cfg.queueProgress(QueueProgress{ID: watch.id, Why: why})
+			}
+			continue
+		}
+		// An item that left the queue either started its build, was cancelled or is about to start.
+		// Only the latter is looked up again on the next poll.
+		item, err := w.queue.pollQueueItem(ctx, watch.id, watch.cfg)
+		if err != nil && retryablePollError(ctx, err) {
+			continue
+		}
+		if err != nil || item.Number != 0 {
+			w.resolveQueue(watch, QueueUpdate{ID: watch.id, Item: item, Err: err})
+		}
+	}
+}
+
+// pollBuilds fetches the recent builds of a job once and only looks up watched builds older than those.
+func (w *Watcher) pollBuilds(ctx context.Context, jobURL string, watches []*buildWatch) {
+	recent, err := w.recentBuilds(ctx, jobURL)
+	if err != nil {
+		if !retryablePollError(ctx, err) {
+			for _, watch := range watches {
+				w.resolveBuild(watch, BuildUpdate{Item: watch.item, Err: err})
+			}
+		}
+		return
+	}
+
+	now := time.Now()
+	for _, watch := range watches {
+		var info BuildInfo
+		var err error
+		if build, ok := recent[watch.item.Number]; ok {
+			info = w.reportBuild(ctx, watch, build.progress(now))
+		} else {
+			info, err = w.jobs.pollBuild(ctx, watch.item, watch.cfg)
+			if err != nil && retryablePollError(ctx, err) {
+				continue
+			}
+		}
+		if err != nil || !info.Building {
+			w.resolveBuild(watch, BuildUpdate{Item: watch.item, Info: info, Err: err})
+		}
+	}
+}
+
+// retryablePollError reports whether a watch is kept after err, as it is transient or the poll
+// timed out or was stopped.
+func retryablePollError(ctx context.Context, err error) bool {
+	return isTransient(err) || ctx.Err() != nil
+}
+
+// reportBuild reports the progress of a build fetched with the recent builds of its job.
+func (w *Watcher) reportBuild(ctx context.Context, watch *buildWatch, progress BuildProgress) BuildInfo {
+	if watch.cfg.buildProgress == nil {
+		return progress.Info
+	}
+	if progress.Info.Building {
+		progress.Stage = w.jobs.runningStage(ctx, watch.item)
+	}
+	watch.cfg.buildProgress(progress)
+	return progress.Info
+}
+
+// waitingQueueIDs returns the ids of the items waiting in the queue and why they are waiting.
+func (w *Watcher) waitingQueueIDs(ctx context.Context) (map[QueueID]string, error) {
+	var queueResponse struct {
+		Items []struct {
+			ID  QueueID
+			Why string
+		}
+	}
+
+	resp := w.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    w.queue.URLBuilder.JSONEndpoint("queue"),
+		Route:  "queue/api/json",
+		Query:  url.Values{"tree": []string{"items[id,why]"}},
+	})
+	if err := resp.VerifyAndDecode(JsonDecoder(&queueResponse)); err != nil {
+		return nil, err
+	}
+
+	waiting := make(map[QueueID]string, len(queueResponse.Items))
+	for _, item := range queueResponse.Items {
+		waiting[item.ID] = item.Why
+	}
+	return waiting, nil
+}
+
+func (w *Watcher) recentBuilds(ctx context.Context, jobURL string) (map[BuildNumber]buildTiming, error) {
+	var buildInfoResponse struct {
+		Builds []buildTiming
+	}
+
+	resp := w.requestor.Do(ctx, Request{
+		Method: http.MethodGet,
+		URL:    fmt.Sprintf("%v/%v", jobURL, jsonEndpoint),
+		Route:  "job/{name}/api/json",
+		Query:  url.Values{"tree": []string{fmt.Sprintf("builds[%v]{0,%v}", buildProgressTree, watcherBuildWindow)}},
+	})
+	if err := resp.VerifyAndDecode(JsonDecoder(&buildInfoResponse)); err != nil {
+		return nil, err
+	}
+
+	recent := make(map[BuildNumber]buildTiming, len(buildInfoResponse.Builds))
+	for _, build := range buildInfoResponse.Builds {
+		recent[build.Number] = build
+	}
+	return recent, nil
+}
+
+func (w *Watcher) resolveQueue(watch *queueWatch, update QueueUpdate) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.queued[watch]; !ok {
+		return
+	}
+	delete(w.queued, watch)
+	watch.ch <- update
+	close(watch.ch)
+	close(watch.done)
+}
+
+func (w *Watcher) resolveBuild(watch *buildWatch, update BuildUpdate) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.builds[watch]; !ok {
+		return
+	}
+	delete(w.builds, watch)
+	watch.ch <- update
+	close(watch.ch)
+	close(watch.done)
+}
+
+// jobURLOf returns the url of the job of a build, e.g. http://host/job/test for http://host/job/test/2/.
+func jobURLOf(buildURL string) string {
+	buildURL = strings.TrimSuffix(buildURL, "/")
+	if idx := strings.LastIndex(buildURL, "/"); idx >= 0 {
+		return buildURL[:idx]
+	}
+	return buildURL
+}
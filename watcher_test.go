@@ -0,0 +1,235 @@
+package gojenkins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatcher_BatchesRequests(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	count := func(req *http.Request) int {
+		mu.Lock()
+		defer mu.Unlock()
+		requests[req.URL.Path]++
+		return requests[req.URL.Path]
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/queue/api/json", func(resp http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("tree") != "items[id,why]" {
+			t.Errorf("Expected only the ids of queue items to be requested but got %v", req.URL.RawQuery)
+		}
+		// Queue item 1 leaves the queue after the first poll, queue item 2 after the second.
+		switch count(req) {
+		case 1:
+			fmt.Fprint(resp, `{"items":[{"id":1},{"id":2}]}`)
+		case 2:
+			fmt.Fprint(resp, `{"items":[{"id":2}]}`)
+		default:
+			fmt.Fprint(resp, `{"items":[]}`)
+		}
+	})
+	mux.HandleFunc("/queue/item/", func(resp http.ResponseWriter, req *http.Request) {
+		count(req)
+		id := strings.Split(req.URL.Path, "/")[3]
+		fmt.Fprintf(resp, `{"executable":{"number":%v,"url":"http://testurl.com/job/Test/%v/"}}`, id, id)
+	})
+	mux.HandleFunc("/job/Test/api/json", func(resp http.ResponseWriter, req *http.Request) {
+		// Build 5 completes after the first poll, build 6 after the second.
+		switch count(req) {
+		case 1:
+			fmt.Fprint(resp, `{"builds":[{"number":6,"building":true},{"number":5,"building":true}]}`)
+		case 2:
+			fmt.Fprint(resp, `{"builds":[{"number":6,"building":true},{"number":5,"result":"SUCCESS"}]}`)
+		default:
+			fmt.Fprint(resp, `{"builds":[{"number":6,"result":"FAILURE"},{"number":5,"result":"SUCCESS"}]}`)
+		}
+	})
+	srvr := httptest.NewServer(mux)
+	defer srvr.Close()
+
+	watcher := NewWatcher(URLBuilder(srvr.URL), BasicAuthRequestor("", ""), 5*time.Millisecond)
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Second)
+	defer cancelFn()
+
+	queueUpdates := []<-chan QueueUpdate{watcher.WatchQueueItem(ctx, 1), watcher.WatchQueueItem(ctx, 2)}
+	buildUpdates := []<-chan BuildUpdate{
+		watcher.WatchBuild(ctx, QueueItem{Number: 5, URL: srvr.URL + "/job/Test/5/"}),
+		watcher.WatchBuild(ctx, QueueItem{Number: 6, URL: srvr.URL + "/job/Test/6/"}),
+	}
+
+	for i, updates := range queueUpdates {
+		update := <-updates
+		if update.Err != nil || update.Item.Number != BuildNumber(i+1) {
+			t.Errorf("Expected queue item %v to start build %v but got %v", i+1, i+1, update)
+		}
+		if _, ok := <-updates; ok {
+			t.Errorf("Expected the channel to be closed after the update")
+		}
+	}
+	expectedResults := []string{"SUCCESS", "FAILURE"}
+	for i, updates := range buildUpdates {
+		update := <-updates
+		if update.Err != nil || update.Info.Result != expectedResults[i] {
+			t.Errorf("Expected build %v to complete with %v but got %v", update.Item.Number, expectedResults[i], update)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests["/queue/item/1/api/json"] != 1 || requests["/queue/item/2/api/json"] != 1 {
+		t.Errorf("Expected queue items to be looked up once after leaving the queue but got %v", requests)
+	}
+	if requests["/job/Test/api/json"] > 3 {
+		t.Errorf("Expected builds of a job to be fetched once per poll but got %v", requests)
+	}
+}
+
+func TestWatcher_LooksUpBuildsOutsideOfTheRecentBuilds(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job/Test/api/json", stringResponseHandleFunc(`{"builds":[{"number":9,"building":true}]}`))
+	mux.HandleFunc("/job/Test/2/api/json", stringResponseHandleFunc(buildCompleteResponse))
+	srvr := httptest.NewServer(mux)
+	defer srvr.Close()
+
+	watcher := NewWatcher(URLBuilder(srvr.URL), BasicAuthRequestor("", ""), time.Millisecond)
+	info, err := watcher.WaitUntilBuildIsComplete(context.TODO(), QueueItem{Number: 2, URL: srvr.URL + "/job/Test/2"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Expected no error but got %v", err)
+	}
+	if info.Result != "SUCCESS" {
+		t.Errorf("Expected SUCCESS but got %v", info.Result)
+	}
+}
+
+func TestWatcher_StopsOnError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/queue/api/json", func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusForbidden)
+	})
+	srvr := httptest.NewServer(mux)
+	defer srvr.Close()
+
+	watcher := NewWatcher(URLBuilder(srvr.URL), BasicAuthRequestor("", ""), time.Millisecond)
+	_, err := watcher.WaitUntilBuildIsQueued(context.TODO(), 1, time.Hour)
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("Expected a 403 error but got %v", err)
+	}
+}
+
+func TestWatcher_StopsWatchingCancelledQueueItems(t *testing.T) {
+	var mu sync.Mutex
+	var itemRequests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/queue/api/json", stringResponseHandleFunc(`{"items":[]}`))
+	mux.HandleFunc("/queue/item/", func(resp http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		itemRequests++
+		mu.Unlock()
+		fmt.Fprint(resp, `{"cancelled":true,"executable":null}`)
+	})
+	srvr := httptest.NewServer(mux)
+	defer srvr.Close()
+
+	watcher := NewWatcher(URLBuilder(srvr.URL), BasicAuthRequestor("", ""), time.Millisecond)
+	_, err := watcher.WaitUntilBuildIsQueued(context.TODO(), 1, time.Hour)
+	if !errors.Is(err, ErrQueueItemCancelled) {
+		t.Fatalf("Expected %v but got %v", ErrQueueItemCancelled, err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if itemRequests != 1 {
+		t.Errorf("Expected the cancelled queue item to be fetched once but was fetched %v times", itemRequests)
+	}
+}
+
+func TestWatcher_TimesoutCorrectly(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/queue/api/json", stringResponseHandleFunc(`{"items":[{"id":1}]}`))
+	srvr := httptest.NewServer(mux)
+	defer srvr.Close()
+
+	watcher := NewWatcher(URLBuilder(srvr.URL), BasicAuthRequestor("", ""), time.Millisecond)
+	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelFn()
+
+	_, err := watcher.WaitUntilBuildIsQueued(ctx, 1, time.Hour)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Expected %v but got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestWatcher_RetriesHungPolls(t *testing.T) {
+	var queueRequests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/queue/api/json", func(resp http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&queueRequests, 1) == 1 {
+			<-req.Context().Done()
+			return
+		}
+		fmt.Fprint(resp, `{"items":[]}`)
+	})
+	mux.HandleFunc("/queue/item/1/api/json", stringResponseHandleFunc(queueItemWithExecutable))
+	srvr := httptest.NewServer(mux)
+	defer srvr.Close()
+
+	watcher := NewWatcher(URLBuilder(srvr.URL), BasicAuthRequestor("", ""), time.Millisecond)
+	watcher.pollTimeout = 20 * time.Millisecond
+	ctx, cancelFn := context.WithTimeout(context.Background(), time.Second)
+	defer cancelFn()
+
+	if _, err := watcher.WaitUntilBuildIsQueued(ctx, 1, time.Hour); err != nil {
+		t.Fatalf("Expected the hung poll to be retried but got %v", err)
+	}
+}
+
+func TestWatcher_Stop(t *testing.T) {
+	requestCancelled := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/queue/api/json", func(resp http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+		close(requestCancelled)
+	})
+	srvr := httptest.NewServer(mux)
+	defer srvr.Close()
+
+	watcher := NewWatcher(URLBuilder(srvr.URL), BasicAuthRequestor("", ""), time.Millisecond)
+	updates := watcher.WatchQueueItem(context.TODO(), 1)
+	time.Sleep(10 * time.Millisecond)
+	watcher.Stop()
+
+	if update := <-updates; update.Err != ErrWatcherStopped {
+		t.Errorf("Expected %v but got %v", ErrWatcherStopped, update.Err)
+	}
+	select {
+	case <-requestCancelled:
+	case <-time.After(time.Second):
+		t.Errorf("Expected the request in flight to be cancelled")
+	}
+	if update := <-watcher.WatchQueueItem(context.TODO(), 2); update.Err != ErrWatcherStopped {
+		t.Errorf("Expected watches after Stop to receive %v but got %v", ErrWatcherStopped, update.Err)
+	}
+}
+
+func TestJobURLOf(t *testing.T) {
+	tests := map[string]string{
+		"http://testurl.com/jenkins/job/Test/2/": "http://testurl.com/jenkins/job/Test",
+		"http://testurl.com/jenkins/job/Test/2":  "http://testurl.com/jenkins/job/Test",
+	}
+	for buildURL, expectedJobURL := range tests {
+		if jobURL := jobURLOf(buildURL); jobURL != expectedJobURL {
+			t.Errorf("Expected %v for %v but got %v", expectedJobURL, buildURL, jobURL)
+		}
+	}
+}